@@ -0,0 +1,163 @@
+package announcement
+
+import (
+	"pic/i18n"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 公告面向的人群
+const (
+	AudienceAll    = "all"    // 所有人
+	AudienceUsers  = "users"  // 仅登录用户
+	AudiencePublic = "public" // 仅公开图库访客
+)
+
+// 公告级别
+const (
+	LevelInfo     = "info"
+	LevelWarning  = "warning"
+	LevelCritical = "critical"
+)
+
+// Announcement 站点公告（维护窗口、政策变更等）
+type Announcement struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	Title    string `gorm:"size:200;not null" json:"title"`
+	Content  string `gorm:"type:text" json:"content"`
+	Level    string `gorm:"size:16;default:info" json:"level"`
+	Audience string `gorm:"size:16;default:all;index" json:"audience"`
+	// StartsAt/EndsAt 为空表示立即生效/永不过期
+	StartsAt *time.Time `gorm:"index" json:"starts_at"`
+	EndsAt   *time.Time `gorm:"index" json:"ends_at"`
+	// Dismissible 为 true 时用户可以关闭，关闭记录见 Dismissal
+	Dismissible bool `json:"dismissible"`
+	// Version 每次修改加一，内容变化后已关闭的公告会重新显示
+	Version   int       `gorm:"default:1" json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Dismissal 用户关闭公告的记录，按 Owner（用户或会话）和公告版本保存，
+// 公告修改后版本变化，已关闭的公告会重新显示
+type Dismissal struct {
+	Owner          string `gorm:"size:80;primaryKey"`
+	AnnouncementID uint   `gorm:"primaryKey"`
+	Version        int    `gorm:"not null"`
+	UpdatedAt      time.Time
+}
+
+// TableName 关闭记录表名
+func (Dismissal) TableName() string {
+	return "announcement_dismissals"
+}
+
+var db *gorm.DB
+
+// Init 建表
+func Init(database *gorm.DB) error {
+	db = database
+	return db.AutoMigrate(&Announcement{}, &Dismissal{})
+}
+
+// Validate 检查公告字段
+func (a *Announcement) Validate() error {
+	if a.Title == "" {
+		return i18n.NewError(i18n.AnnouncementNoTitle)
+	}
+	switch a.Level {
+	case "":
+		a.Level = LevelInfo
+	case LevelInfo, LevelWarning, LevelCritical:
+	default:
+		return i18n.NewError(i18n.AnnouncementBadLevel)
+	}
+	switch a.Audience {
+	case "":
+		a.Audience = AudienceAll
+	case AudienceAll, AudienceUsers, AudiencePublic:
+	default:
+		return i18n.NewError(i18n.AnnouncementBadAudience)
+	}
+	if a.StartsAt != nil && a.EndsAt != nil && !a.EndsAt.After(*a.StartsAt) {
+		return i18n.NewError(i18n.AnnouncementBadPeriod)
+	}
+	return nil
+}
+
+// Active 返回当前生效、面向指定人群的公告，按级别和时间排序。
+// owner 不为空时排除其已关闭的当前版本公告
+func Active(audience, owner string) ([]Announcement, error) {
+	now := time.Now()
+	var list []Announcement
+	query := db.
+		Where("audience IN ?", []string{AudienceAll, audience}).
+		Where("starts_at IS NULL OR starts_at <= ?", now).
+		Where("ends_at IS NULL OR ends_at > ?", now)
+	if owner != "" {
+		// 管理员取消 dismissible 后公告重新对所有人显示
+		query = query.Where("NOT (dismissible AND EXISTS (?))", db.Model(&Dismissal{}).
+			Select("1").
+			Where("owner = ?", owner).
+			Where("announcement_id = announcements.id AND version = announcements.version"))
+	}
+	err := query.
+		Order("CASE level WHEN 'critical' THEN 0 WHEN 'warning' THEN 1 ELSE 2 END").
+		Order("created_at DESC").
+		Find(&list).Error
+	return list, err
+}
+
+// List 返回全部公告（管理端）
+func List() ([]Announcement, error) {
+	var list []Announcement
+	err := db.Order("created_at DESC").Find(&list).Error
+	return list, err
+}
+
+// Get 按 ID 查询
+func Get(id uint) (*Announcement, error) {
+	var a Announcement
+	if err := db.First(&a, id).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Create 新建公告
+func Create(a *Announcement) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	a.ID, a.Version = 0, 1
+	return db.Create(a).Error
+}
+
+// Save 保存修改并递增版本号
+func Save(a *Announcement) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	a.Version++
+	return db.Save(a).Error
+}
+
+// Delete 删除公告及其关闭记录
+func Delete(id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("announcement_id = ?", id).Delete(&Dismissal{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Announcement{}, id).Error
+	})
+}
+
+// Dismiss 记录 owner 关闭了公告的当前版本，重复关闭只更新版本
+func Dismiss(owner string, a *Announcement) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "owner"}, {Name: "announcement_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"version", "updated_at"}),
+	}).Create(&Dismissal{Owner: owner, AnnouncementID: a.ID, Version: a.Version}).Error
+}
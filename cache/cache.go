@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store 响应缓存的存储后端。所有方法在出错时按未命中处理，缓存故障不影响正常请求。
+type Store interface {
+	// Get 读取缓存值
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set 写入缓存值并设置过期时间
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	// Generation 返回命名空间当前的代数，缓存键包含代数
+	Generation(ctx context.Context, namespace string) int64
+	// Bump 让命名空间代数加一，使该命名空间下的旧缓存全部失效
+	Bump(ctx context.Context, namespace string)
+}
+
+// Default 全局缓存存储，为 nil 时不缓存
+var Default Store
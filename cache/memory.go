@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// MemoryStore 进程内缓存，只适用于单实例部署。条目数达到上限时淘汰最久未使用的条目。
+type MemoryStore struct {
+	mu          sync.Mutex
+	entries     map[string]*list.Element
+	lru         *list.List // 元素为 *memoryEntry，队首为最近使用
+	generations map[string]int64
+	maxEntries  int
+}
+
+// NewMemoryStore 创建进程内缓存，maxEntries 为最多缓存的条目数，并启动过期清理
+func NewMemoryStore(ctx context.Context, maxEntries int) *MemoryStore {
+	s := &MemoryStore{
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
+		generations: make(map[string]int64),
+		maxEntries:  maxEntries,
+	}
+	go s.janitor(ctx)
+	return s
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*memoryEntry)
+	if time.Now().After(e.expires) {
+		s.removeLocked(el)
+		return nil, false
+	}
+	s.lru.MoveToFront(el)
+	return e.value, true
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires := time.Now().Add(ttl)
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*memoryEntry)
+		e.value, e.expires = value, expires
+		s.lru.MoveToFront(el)
+		return
+	}
+	for s.lru.Len() >= s.maxEntries && s.lru.Len() > 0 {
+		s.removeLocked(s.lru.Back())
+	}
+	s.entries[key] = s.lru.PushFront(&memoryEntry{key: key, value: value, expires: expires})
+}
+
+func (s *MemoryStore) removeLocked(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+}
+
+func (s *MemoryStore) Generation(_ context.Context, namespace string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generations[namespace]
+}
+
+func (s *MemoryStore) Bump(_ context.Context, namespace string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generations[namespace]++
+}
+
+func (s *MemoryStore) janitor(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			s.mu.Lock()
+			for el := s.lru.Front(); el != nil; {
+				next := el.Next()
+				if now.After(el.Value.(*memoryEntry).expires) {
+					s.removeLocked(el)
+				}
+				el = next
+			}
+			s.mu.Unlock()
+		}
+	}
+}
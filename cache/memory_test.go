@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreLRU(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewMemoryStore(ctx, 3)
+
+	for _, k := range []string{"a", "b", "c"} {
+		s.Set(ctx, k, []byte(k), time.Minute)
+	}
+	// 读取 a 使其成为最近使用，写入 d 时淘汰 b
+	if _, ok := s.Get(ctx, "a"); !ok {
+		t.Fatal("a 未命中")
+	}
+	s.Set(ctx, "d", []byte("d"), time.Minute)
+	// 覆盖已有的 c 不淘汰其它条目
+	s.Set(ctx, "c", []byte("c2"), time.Minute)
+
+	for _, tc := range []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{"a", "a", true},
+		{"b", "", false},
+		{"c", "c2", true},
+		{"d", "d", true},
+	} {
+		v, ok := s.Get(ctx, tc.key)
+		if ok != tc.ok || string(v) != tc.want {
+			t.Errorf("Get(%s) = %q, %v，期望 %q, %v", tc.key, v, ok, tc.want, tc.ok)
+		}
+	}
+	if n := s.lru.Len(); n != 3 || len(s.entries) != 3 {
+		t.Fatalf("条目数 list=%d map=%d，期望 3", n, len(s.entries))
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewMemoryStore(ctx, 10)
+	s.Set(ctx, "k", []byte("v"), -time.Second)
+	if _, ok := s.Get(ctx, "k"); ok {
+		t.Fatal("过期条目仍然命中")
+	}
+	if len(s.entries) != 0 || s.lru.Len() != 0 {
+		t.Fatal("读取时未删除过期条目")
+	}
+}
+
+func TestMemoryStoreGeneration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := NewMemoryStore(ctx, 10)
+	if g := s.Generation(ctx, "ns"); g != 0 {
+		t.Fatalf("初始代数 = %d", g)
+	}
+	s.Bump(ctx, "ns")
+	s.Bump(ctx, "ns")
+	if g := s.Generation(ctx, "ns"); g != 2 {
+		t.Fatalf("代数 = %d，期望 2", g)
+	}
+	if g := s.Generation(ctx, "other"); g != 0 {
+		t.Fatalf("其它命名空间代数 = %d", g)
+	}
+}
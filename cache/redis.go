@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisPrefix = "pic:cache:"
+
+// RedisStore 基于 Redis 的缓存，多个实例共享缓存和失效状态
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore 创建 Redis 缓存
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, bool) {
+	value, err := s.client.Get(ctx, redisPrefix+key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			slog.Debug("读取 Redis 缓存失败", "key", key, "error", err)
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	if err := s.client.Set(ctx, redisPrefix+key, value, ttl).Err(); err != nil {
+		slog.Debug("写入 Redis 缓存失败", "key", key, "error", err)
+	}
+}
+
+func (s *RedisStore) Generation(ctx context.Context, namespace string) int64 {
+	gen, err := s.client.Get(ctx, redisPrefix+"gen:"+namespace).Int64()
+	if err != nil {
+		return 0
+	}
+	return gen
+}
+
+func (s *RedisStore) Bump(ctx context.Context, namespace string) {
+	if err := s.client.Incr(ctx, redisPrefix+"gen:"+namespace).Err(); err != nil {
+		slog.Warn("缓存失效失败", "namespace", namespace, "error", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package cluster 多实例部署的协调原语：分布式锁和实例间广播。
+//
+// 配置 Redis 后在实例间共享的状态：响应缓存及其失效代数、Last-Modified 修改水位、限流计数（cache.Default
+// 和 middleware.SetRateLimiter 选用 Redis 实现）、后台任务队列（数据库）、系统设置（数据库，修改后广播，
+// 定时同步兜底）、定时清理（非 PerInstance 任务取得集群锁后执行）。
+//
+// 有意保留在每个实例本地的状态：
+//   - 未配置 Redis 时的进程内缓存、修改水位和限流（cache.MemoryStore、ratelimit.MemoryLimiter），只适合单实例；
+//   - 服务器配置及其热加载（SIGHUP 只重新加载收到信号的实例）；
+//   - 本机临时目录和对它的清理、本地存储的磁盘空间监控（diskusage）；
+//   - 健康检查中 GitHub 连通性的短期缓存、最近一次清理报告（maintenance.Last）、访问日志文件。
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	lockPrefix  = "pic:lock:"
+	eventPrefix = "pic:events:"
+)
+
+var (
+	client     *redis.Client
+	instanceID = newInstanceID()
+
+	mu          sync.RWMutex
+	subscribers = map[string][]func(payload string){}
+)
+
+// event 实例间广播的消息
+type event struct {
+	From    string `json:"from"`
+	Payload string `json:"payload,omitempty"`
+}
+
+// Init 初始化多实例部署时的协调原语（分布式锁和实例间广播），ctx 结束时停止接收广播。
+// rdb 为 nil 时退化为进程内实现，单实例部署的行为不变。
+func Init(ctx context.Context, rdb *redis.Client) {
+	client = rdb
+	if client == nil {
+		return
+	}
+
+	pubsub := client.PSubscribe(ctx, eventPrefix+"*")
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				var e event
+				if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil || e.From == instanceID {
+					continue
+				}
+				dispatch(msg.Channel[len(eventPrefix):], e.Payload)
+			}
+		}
+	}()
+	slog.Info("🔗 已启用多实例协调", "instance", instanceID)
+}
+
+// InstanceID 当前实例的标识（主机名 + 随机后缀），用于日志和锁的持有者
+func InstanceID() string {
+	return instanceID
+}
+
+// Subscribe 注册 topic 的回调，只接收其它实例发出的广播（本实例的修改应在发出前自行生效）
+func Subscribe(topic string, fn func(payload string)) {
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers[topic] = append(subscribers[topic], fn)
+}
+
+// Publish 通知其它实例，未配置 Redis 时为空操作
+func Publish(ctx context.Context, topic, payload string) error {
+	if client == nil {
+		return nil
+	}
+	data, _ := json.Marshal(event{From: instanceID, Payload: payload})
+	return client.Publish(ctx, eventPrefix+topic, data).Err()
+}
+
+func dispatch(topic, payload string) {
+	mu.RLock()
+	fns := subscribers[topic]
+	mu.RUnlock()
+	for _, fn := range fns {
+		fn(payload)
+	}
+}
+
+func newInstanceID() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "pic"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
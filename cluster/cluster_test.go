@@ -0,0 +1,56 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时: %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// 收到其它实例的广播；ctx 结束后停止订阅
+func TestInitSubscription(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		rdb.Close()
+		client = nil
+		mu.Lock()
+		delete(subscribers, "test:init")
+		mu.Unlock()
+	})
+
+	got := make(chan string, 1)
+	Subscribe("test:init", func(payload string) { got <- payload })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Init(ctx, rdb)
+	waitFor(t, "订阅建立", func() bool { return mr.PubSubNumPat() == 1 })
+
+	data, _ := json.Marshal(event{From: "other", Payload: "hello"})
+	mr.Publish(eventPrefix+"test:init", string(data))
+	select {
+	case p := <-got:
+		if p != "hello" {
+			t.Fatalf("payload = %q", p)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("没有收到广播")
+	}
+
+	cancel()
+	waitFor(t, "ctx 结束后取消订阅", func() bool { return mr.PubSubNumPat() == 0 })
+}
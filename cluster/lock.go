@@ -0,0 +1,156 @@
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotHeld 锁已过期或被其它持有者取得
+var ErrNotHeld = errors.New("锁已不再持有")
+
+// 只有持有者才能释放/续期，避免锁过期后误删其它实例取得的锁
+var (
+	unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+	refreshScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+// 未配置 Redis 时的进程内锁：锁名 -> 持有者令牌和过期时间
+var (
+	localMu    sync.Mutex
+	localLocks = map[string]localLock{}
+)
+
+type localLock struct {
+	token   string
+	expires time.Time
+}
+
+// Lock 一把已取得的锁，ttl 到期后自动释放，防止持有者崩溃后永久占用
+type Lock struct {
+	name  string
+	token string
+}
+
+// TryLock 尝试取得名为 name 的锁，已被其它持有者占用时返回 (nil, false, nil)
+func TryLock(ctx context.Context, name string, ttl time.Duration) (*Lock, bool, error) {
+	l := &Lock{name: name, token: instanceID + ":" + randomToken()}
+
+	if client == nil {
+		localMu.Lock()
+		defer localMu.Unlock()
+		if held, ok := localLocks[name]; ok && time.Now().Before(held.expires) {
+			return nil, false, nil
+		}
+		localLocks[name] = localLock{token: l.token, expires: time.Now().Add(ttl)}
+		return l, true, nil
+	}
+
+	ok, err := client.SetNX(ctx, lockPrefix+name, l.token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return l, true, nil
+}
+
+// Refresh 延长锁的有效期，用于执行时间可能超过 ttl 的任务
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if client == nil {
+		localMu.Lock()
+		defer localMu.Unlock()
+		if held, ok := localLocks[l.name]; !ok || held.token != l.token || time.Now().After(held.expires) {
+			return ErrNotHeld
+		}
+		localLocks[l.name] = localLock{token: l.token, expires: time.Now().Add(ttl)}
+		return nil
+	}
+
+	n, err := refreshScript.Run(ctx, client, []string{lockPrefix + l.name}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Unlock 释放锁，锁已过期时为空操作
+func (l *Lock) Unlock(ctx context.Context) error {
+	if client == nil {
+		localMu.Lock()
+		defer localMu.Unlock()
+		if held, ok := localLocks[l.name]; ok && held.token == l.token {
+			delete(localLocks, l.name)
+		}
+		return nil
+	}
+	return unlockScript.Run(ctx, client, []string{lockPrefix + l.name}, l.token).Err()
+}
+
+// WithLock 取得锁后执行 fn（例如只需一个实例执行的定时任务），锁被占用时跳过并返回 false。
+// 执行期间每 ttl/3 续期一次，fn 可以运行超过 ttl；锁丢失（过期后被其它实例取得）时取消 fn 的 ctx。
+func WithLock(ctx context.Context, name string, ttl time.Duration, fn func(ctx context.Context) error) (bool, error) {
+	l, ok, err := TryLock(ctx, name, ttl)
+	if err != nil || !ok {
+		return false, err
+	}
+	defer l.Unlock(context.Background())
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		l.keepAlive(fnCtx, ttl, cancel)
+	}()
+	err = fn(fnCtx)
+	cancel()
+	<-stopped
+	return true, err
+}
+
+// keepAlive 定时续期锁直到 ctx 结束，锁已不再持有时调用 lost
+func (l *Lock) keepAlive(ctx context.Context, ttl time.Duration, lost context.CancelFunc) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := l.Refresh(ctx, ttl)
+			if errors.Is(err, ErrNotHeld) {
+				slog.Warn("⚠️ 集群锁已丢失，停止执行", "lock", l.name)
+				lost()
+				return
+			}
+			// Redis 暂时不可用时继续尝试，锁在 ttl 内仍然有效
+			if err != nil && ctx.Err() == nil {
+				slog.Warn("续期集群锁失败", "lock", l.name, "error", err)
+			}
+		}
+	}
+}
+
+func randomToken() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fn 运行超过 ttl 时锁被续期，其它持有者取不到；返回后锁被释放
+func TestWithLockRefresh(t *testing.T) {
+	const ttl = 30 * time.Millisecond
+	ran, err := WithLock(context.Background(), "test:refresh", ttl, func(ctx context.Context) error {
+		for i := 0; i < 5; i++ {
+			time.Sleep(ttl / 2)
+			if _, ok, _ := TryLock(context.Background(), "test:refresh", ttl); ok {
+				return errors.New("锁在执行期间被其它持有者取得")
+			}
+		}
+		return ctx.Err()
+	})
+	if !ran || err != nil {
+		t.Fatalf("WithLock = %v, %v", ran, err)
+	}
+	l, ok, _ := TryLock(context.Background(), "test:refresh", ttl)
+	if !ok {
+		t.Fatal("WithLock 返回后锁没有释放")
+	}
+	l.Unlock(context.Background())
+}
+
+// 锁被其它持有者取得后续期失败，fn 的 ctx 被取消
+func TestWithLockLost(t *testing.T) {
+	const ttl = 30 * time.Millisecond
+	ran, err := WithLock(context.Background(), "test:lost", ttl, func(ctx context.Context) error {
+		localMu.Lock()
+		localLocks["test:lost"] = localLock{token: "other", expires: time.Now().Add(time.Hour)}
+		localMu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return errors.New("锁丢失后 ctx 没有被取消")
+		}
+	})
+	if !ran || !errors.Is(err, context.Canceled) {
+		t.Fatalf("WithLock = %v, %v", ran, err)
+	}
+	localMu.Lock()
+	defer localMu.Unlock()
+	if held := localLocks["test:lost"]; held.token != "other" {
+		t.Fatalf("释放了其它持有者的锁: %+v", held)
+	}
+}
+
+func TestRefreshNotHeld(t *testing.T) {
+	l, ok, _ := TryLock(context.Background(), "test:expired", 10*time.Millisecond)
+	if !ok {
+		t.Fatal("TryLock 失败")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := l.Refresh(context.Background(), time.Second); !errors.Is(err, ErrNotHeld) {
+		t.Fatalf("过期后 Refresh = %v, 期望 ErrNotHeld", err)
+	}
+}
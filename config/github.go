@@ -0,0 +1,104 @@
+package config
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"pic/i18n"
+	"strings"
+	"time"
+)
+
+const gitHubAPIHost = "api.github.com"
+
+// NewGitHubTransport 按 github.api_url / github.proxy 配置创建访问 GitHub 专用的 Transport，
+// 请求经过代理或被改写到镜像地址，其它 HTTP 客户端不受影响。
+// api_url 为替代 https://api.github.com 的镜像/反代地址；proxy 为空时沿用 HTTPS_PROXY 等环境变量。
+// observe 不为空时在每个 GitHub 请求完成后调用，由调用方记录指标和日志。
+func NewGitHubTransport(observe GitHubObserver) (http.RoundTripper, error) {
+	github := Current().GitHub
+	apiURLValue, proxyValue := github.APIURL, github.Proxy
+
+	base := defaultTransport()
+	rt := &gitHubRoundTripper{base: base, github: base, observe: observe}
+
+	if proxyValue != "" {
+		proxyURL, err := url.Parse(proxyValue)
+		if err != nil || proxyURL.Host == "" {
+			return nil, i18n.NewError(i18n.ConfigBadValue, "github.proxy", proxyValue)
+		}
+		gh := base.Clone()
+		gh.Proxy = http.ProxyURL(proxyURL)
+		rt.github = gh
+		slog.Info("🌐 GitHub 请求将通过代理", "proxy", proxyURL.Redacted())
+	}
+
+	if apiURLValue != "" {
+		apiURL, err := url.Parse(strings.TrimSuffix(apiURLValue, "/"))
+		if err != nil || apiURL.Scheme == "" || apiURL.Host == "" {
+			return nil, i18n.NewError(i18n.ConfigBadValue, "github.api_url", apiURLValue)
+		}
+		rt.apiURL = apiURL
+		slog.Info("🌐 GitHub API 将被改写", "api_url", apiURL.Redacted())
+	}
+	return rt, nil
+}
+
+// defaultTransport 复制标准库默认 Transport 的设置（连接池、超时、环境变量代理）
+func defaultTransport() *http.Transport {
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		return t.Clone()
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// GitHubObserver 观察 GitHub 请求的结果，resp 和 err 只有一个不为空；req 为改写后的请求
+type GitHubObserver func(req *http.Request, resp *http.Response, err error)
+
+// gitHubRoundTripper 对 GitHub 相关域名的请求使用单独的 Transport，并按需改写 API 地址
+type gitHubRoundTripper struct {
+	base    http.RoundTripper
+	github  http.RoundTripper
+	apiURL  *url.URL
+	observe GitHubObserver
+}
+
+func (t *gitHubRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isGitHubHost(req.URL.Hostname()) {
+		return t.base.RoundTrip(req)
+	}
+
+	if t.apiURL != nil && strings.EqualFold(req.URL.Hostname(), gitHubAPIHost) {
+		// RoundTripper 不允许修改原请求，这里克隆后再改写
+		req = req.Clone(req.Context())
+		req.URL.Scheme = t.apiURL.Scheme
+		req.URL.Host = t.apiURL.Host
+		req.URL.Path = t.apiURL.Path + req.URL.Path
+		if req.URL.RawPath != "" {
+			req.URL.RawPath = t.apiURL.EscapedPath() + req.URL.RawPath
+		}
+		req.Host = ""
+	}
+
+	resp, err := t.github.RoundTrip(req)
+	if t.observe != nil {
+		t.observe(req, resp, err)
+	}
+	return resp, err
+}
+
+// isGitHubHost 判断是否为 GitHub 及其静态资源域名
+func isGitHubHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "github.com" ||
+		strings.HasSuffix(host, ".github.com") ||
+		host == "githubusercontent.com" ||
+		strings.HasSuffix(host, ".githubusercontent.com")
+}
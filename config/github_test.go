@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestGitHubRoundTripper(t *testing.T) {
+	mirror, _ := url.Parse("https://mirror.example.com/github")
+	failed := errors.New("dial failed")
+	for _, tc := range []struct {
+		name     string
+		url      string
+		err      error
+		viaProxy bool
+		wantURL  string
+		observed bool
+	}{
+		{"api rewritten", "https://api.github.com/repos/o/r", nil, true, "https://mirror.example.com/github/repos/o/r", true},
+		{"raw content not rewritten", "https://raw.githubusercontent.com/o/r/main/a.png", nil, true, "https://raw.githubusercontent.com/o/r/main/a.png", true},
+		{"error observed", "https://api.github.com/user", failed, true, "https://mirror.example.com/github/user", true},
+		{"other host untouched", "https://example.com/a", nil, false, "https://example.com/a", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var sentVia string
+			var gotURL string
+			respond := func(via string) roundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					sentVia, gotURL = via, req.URL.String()
+					if tc.err != nil {
+						return nil, tc.err
+					}
+					return httptest.NewRecorder().Result(), nil
+				}
+			}
+			var observed []error
+			rt := &gitHubRoundTripper{
+				base:   respond("base"),
+				github: respond("github"),
+				apiURL: mirror,
+				observe: func(req *http.Request, resp *http.Response, err error) {
+					if (resp == nil) == (err == nil) {
+						t.Errorf("resp 和 err 应只有一个不为空: %v, %v", resp, err)
+					}
+					if req.URL.String() != tc.wantURL {
+						t.Errorf("观察到的请求地址 = %s", req.URL)
+					}
+					observed = append(observed, err)
+				},
+			}
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			_, err := rt.RoundTrip(req)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("err = %v", err)
+			}
+			if gotURL != tc.wantURL || (sentVia == "github") != tc.viaProxy {
+				t.Fatalf("请求经 %s 发往 %s，期望 %s", sentVia, gotURL, tc.wantURL)
+			}
+			if req.URL.String() != tc.url {
+				t.Fatalf("原请求被修改: %s", req.URL)
+			}
+			if (len(observed) == 1) != tc.observed || (tc.observed && observed[0] != tc.err) {
+				t.Fatalf("observe 调用 = %v", observed)
+			}
+		})
+	}
+}
+
+func TestNewGitHubTransport(t *testing.T) {
+	defer func(old *ServerConfig) { current.Store(old) }(Current())
+	before := http.DefaultTransport
+
+	for _, tc := range []struct {
+		name, apiURL, proxy string
+		ok                  bool
+	}{
+		{"defaults", "", "", true},
+		{"mirror and proxy", "https://mirror.example.com/github/", "http://127.0.0.1:7890", true},
+		{"bad api url", "mirror.example.com", "", false},
+		{"bad proxy", "", "127.0.0.1", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultServerConfig()
+			cfg.GitHub.APIURL, cfg.GitHub.Proxy = tc.apiURL, tc.proxy
+			current.Store(cfg)
+
+			rt, err := NewGitHubTransport(nil)
+			if (err == nil) != tc.ok {
+				t.Fatalf("err = %v, 期望成功 = %v", err, tc.ok)
+			}
+			if tc.ok && rt == nil {
+				t.Fatal("没有返回 Transport")
+			}
+			// 只供 GitHub 客户端使用，不能替换进程内其它 HTTP 客户端的默认 Transport
+			if http.DefaultTransport != before {
+				t.Fatal("http.DefaultTransport 被替换")
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis 可选的 Redis 连接，未配置 redis.url 时为 nil
+var Redis *redis.Client
+
+// InitRedis 根据 redis.url（如 redis://:password@localhost:6379/0）连接 Redis。
+// 配置了但连不上时直接退出，避免多实例部署时悄悄退化成各自为政的本地状态。
+func InitRedis() {
+	rawURL := Current().Redis.URL
+	if rawURL == "" {
+		return
+	}
+
+	opts, err := redis.ParseURL(rawURL)
+	if err != nil {
+		slog.Error("redis.url 格式错误", "error", err)
+		os.Exit(1)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		slog.Error("连接 Redis 失败", "addr", opts.Addr, "error", err)
+		os.Exit(1)
+	}
+
+	Redis = client
+	slog.Info("✅ Redis 连接成功", "addr", opts.Addr, "db", opts.DB)
+}
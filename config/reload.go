@@ -0,0 +1,69 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+var (
+	reloadMu    sync.Mutex
+	source      configSource
+	reloadHooks []func(*ServerConfig)
+)
+
+// OnReload 注册配置热加载后的回调，回调按注册顺序执行
+func OnReload(fn func(*ServerConfig)) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	reloadHooks = append(reloadHooks, fn)
+}
+
+// ReloadServerConfig 重新读取启动时的配置文件和环境变量，校验通过后替换当前配置并执行回调。
+// 校验失败时保持原配置不变。返回值为已修改但需要重启才能生效的配置项。
+func ReloadServerConfig() ([]string, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	cfg, err := source.read()
+	if err != nil {
+		return nil, err
+	}
+	old := current.Load()
+	current.Store(cfg)
+	for _, fn := range reloadHooks {
+		fn(cfg)
+	}
+
+	changed := restartRequired(old, cfg)
+	if len(changed) > 0 {
+		slog.Warn("⚠️ 部分配置需要重启后才能生效", "keys", changed)
+	}
+	slog.Info("🔄 配置已重新加载")
+	return changed, nil
+}
+
+// restartRequired 列出只在启动时读取的配置项中发生变化的部分
+func restartRequired(old, cfg *ServerConfig) []string {
+	var changed []string
+	for name, pair := range map[string][2]interface{}{
+		"server":            {old.Server, cfg.Server},
+		"tls":               {old.TLS, cfg.TLS},
+		"proxy":             {old.Proxy, cfg.Proxy},
+		"log.format":        {old.Log.Format, cfg.Log.Format},
+		"access_log":        {old.AccessLog, cfg.AccessLog},
+		"storage.local_dir": {old.Storage.LocalDir, cfg.Storage.LocalDir},
+		"jobs":              {old.Jobs, cfg.Jobs},
+		"maintenance":       {old.Maintenance, cfg.Maintenance},
+		"github":            {old.GitHub, cfg.GitHub},
+		"redis":             {old.Redis, cfg.Redis},
+		"error_reporting":   {old.ErrorReporting, cfg.ErrorReporting},
+	} {
+		if !reflect.DeepEqual(pair[0], pair[1]) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,708 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"pic/i18n"
+	"pic/ratelimit"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration 支持在 YAML 中写 "15s"、"1m" 这样的时长
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return i18n.NewError(i18n.ConfigBadDuration, node.Line, node.Value)
+	}
+	d.Duration = parsed
+	return nil
+}
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+// ServerConfig 部署级别的服务器配置，来自配置文件并可被环境变量覆盖
+type ServerConfig struct {
+	Server struct {
+		// Listen 为 host:port、unix:/path/to/pic.sock，或 systemd（使用 systemd socket 激活传入的监听）
+		Listen          string   `yaml:"listen"`
+		ReadTimeout     Duration `yaml:"read_timeout"`
+		WriteTimeout    Duration `yaml:"write_timeout"`
+		ShutdownTimeout Duration `yaml:"shutdown_timeout"`
+		// FrontendDir 前端构建产物目录，为空时使用内嵌的前端，未内嵌时为 ./frontend/dist
+		FrontendDir string `yaml:"frontend_dir"`
+		// DataDir 数据目录，启动时切换为工作目录，数据库、图片和访问日志等相对路径都位于其中
+		DataDir     string `yaml:"data_dir"`
+		BodyLimitKB int64  `yaml:"body_limit_kb"`
+	} `yaml:"server"`
+
+	Log struct {
+		Level  string `yaml:"level"`
+		Format string `yaml:"format"`
+	} `yaml:"log"`
+
+	// ReadOnly 只读部署（如归档镜像）：拒绝上传、删除和配置修改，图库照常浏览。
+	// 也可以由管理员在系统设置中开启，两者任一为 true 即生效。
+	ReadOnly bool `yaml:"read_only"`
+
+	TLS   TLSConfig   `yaml:"tls"`
+	Proxy ProxyConfig `yaml:"proxy"`
+
+	// HTTPCache 各接口响应的 Cache-Control 头，配合 ETag 支持 304
+	HTTPCache struct {
+		Gallery string `yaml:"gallery"`
+		Images  string `yaml:"images"`
+	} `yaml:"http_cache"`
+
+	Compression CompressionConfig `yaml:"compression"`
+
+	AccessLog AccessLogConfig `yaml:"access_log"`
+	CORS      CORSConfig      `yaml:"cors"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+
+	Metrics AccessControl `yaml:"metrics"`
+	Admin   AccessControl `yaml:"admin"`
+
+	Storage struct {
+		// LocalDir 本地文件存储目录，配置后监控其所在磁盘的剩余空间；为空表示未使用本地存储
+		LocalDir string `yaml:"local_dir"`
+		// MinFreeMB 剩余空间低于该值时拒绝上传，0 表示只监控不拒绝
+		MinFreeMB int `yaml:"min_free_mb"`
+	} `yaml:"storage"`
+
+	Jobs struct {
+		// Workers 本实例执行后台任务的 worker 数，0 表示只入队、由其它实例执行
+		Workers      int      `yaml:"workers"`
+		PollInterval Duration `yaml:"poll_interval"`
+	} `yaml:"jobs"`
+
+	Maintenance struct {
+		// Interval 定时清理间隔，0 表示只能通过管理接口手动执行
+		Interval Duration `yaml:"interval"`
+		// DryRun 为 true 时定时清理只生成报告、不删除
+		DryRun         bool     `yaml:"dry_run"`
+		TempFileMaxAge Duration `yaml:"temp_file_max_age"`
+		JobRetention   Duration `yaml:"job_retention"`
+	} `yaml:"maintenance"`
+
+	GitHub struct {
+		APIURL string `yaml:"api_url"`
+		Proxy  string `yaml:"proxy"`
+		// HealthRepo 为 owner/repo 时就绪探针用 Token 访问该仓库，验证令牌和仓库权限；
+		// 为空时只检查 GitHub API 是否可达
+		HealthRepo string `yaml:"health_repo"`
+		Token      string `yaml:"token"`
+	} `yaml:"github"`
+
+	Redis struct {
+		URL string `yaml:"url"`
+	} `yaml:"redis"`
+
+	SMTP SMTPConfig `yaml:"smtp"`
+
+	ErrorReporting struct {
+		SentryDSN         string `yaml:"sentry_dsn"`
+		SentryEnvironment string `yaml:"sentry_environment"`
+		WebhookURL        string `yaml:"webhook_url"`
+	} `yaml:"error_reporting"`
+}
+
+// SMTPConfig 发送邮件的 SMTP 服务器，Host 为空表示不发送邮件
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// From 发件人，例如 "Pic <noreply@example.com>"
+	From string `yaml:"from"`
+	// TLS 为 starttls（默认，通常配合 587 端口）、tls（直接 TLS，通常为 465 端口）或 none
+	TLS string `yaml:"tls"`
+}
+
+// Enabled 是否配置了 SMTP 服务器
+func (c SMTPConfig) Enabled() bool {
+	return c.Host != ""
+}
+
+// TLSConfig HTTPS 配置：使用证书文件，或通过 ACME（Let's Encrypt）自动申请证书
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// AutocertDomains 自动申请证书的域名，需要 443 端口可从公网访问
+	AutocertDomains  []string `yaml:"autocert_domains"`
+	AutocertEmail    string   `yaml:"autocert_email"`
+	AutocertCacheDir string   `yaml:"autocert_cache_dir"`
+	// RedirectHTTP 不为空时在该地址（如 ":80"）监听 HTTP，跳转到 HTTPS 并处理 ACME 验证请求
+	RedirectHTTP string `yaml:"redirect_http"`
+}
+
+// Enabled 是否启用 HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || len(t.AutocertDomains) > 0
+}
+
+// ProxyConfig 反向代理配置，决定从哪里取得真实客户端 IP（限流、访问日志、白名单都依赖它）
+type ProxyConfig struct {
+	// TrustedProxies 可信代理的 IP/CIDR，只有来自这些地址的请求才读取 ClientIPHeaders。
+	// 为空时不信任任何代理头；通过 unix socket 接入时对端视为 127.0.0.1。
+	TrustedProxies  []string `yaml:"trusted_proxies"`
+	ClientIPHeaders []string `yaml:"client_ip_headers"`
+	// Platform 为 cloudflare/google-app-engine/fly-io 时直接信任平台提供的客户端 IP 头，
+	// 只应在所有流量都经过该平台时使用
+	Platform string `yaml:"platform"`
+}
+
+// platformHeaders 各托管平台提供真实客户端 IP 的请求头
+var platformHeaders = map[string]string{
+	"cloudflare":        "CF-Connecting-IP",
+	"google-app-engine": "X-Appengine-Remote-Addr",
+	"fly-io":            "Fly-Client-IP",
+}
+
+// PlatformHeader 返回 Platform 对应的请求头，未配置时为空
+func (p ProxyConfig) PlatformHeader() string {
+	return platformHeaders[p.Platform]
+}
+
+// CompressionConfig 响应压缩配置
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// GzipLevel 1-9，BrotliLevel 0-11，数值越大压缩率越高、CPU 开销越大
+	GzipLevel   int `yaml:"gzip_level"`
+	BrotliLevel int `yaml:"brotli_level"`
+	// MinSize 小于该字节数的响应不压缩
+	MinSize int `yaml:"min_size"`
+}
+
+// AccessLogConfig 访问日志配置
+type AccessLogConfig struct {
+	// Output 为 "stdout"、"stderr"、"off" 或文件路径
+	Output string `yaml:"output"`
+	// Format 为 "json" 或 "combined"（Apache combined 格式）
+	Format string `yaml:"format"`
+	// MaxSizeMB 单个文件最大大小，Rotate 为 "hourly"/"daily"/""，MaxBackups 为保留的旧文件数
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	Rotate     string `yaml:"rotate"`
+	MaxBackups int    `yaml:"max_backups"`
+	// Exclude 不记录的路径前缀
+	Exclude []string `yaml:"exclude"`
+}
+
+// CORSConfig 跨域策略配置
+type CORSConfig struct {
+	// AllowedOrigins 允许的来源：完整来源（https://a.com）、子域通配（https://*.a.com）或 "*"。
+	// 为空时不允许任何跨域请求（前端与 API 同源部署时不需要跨域）。
+	AllowedOrigins   []string `yaml:"allowed_origins"`
+	AllowedMethods   []string `yaml:"allowed_methods"`
+	AllowedHeaders   []string `yaml:"allowed_headers"`
+	ExposedHeaders   []string `yaml:"exposed_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials"`
+	// MaxAge 预检结果缓存秒数
+	MaxAge int `yaml:"max_age"`
+}
+
+// RateLimitConfig 各类接口的限流配置，格式如 "10/m"，"off" 表示不限制
+type RateLimitConfig struct {
+	Auth    string `yaml:"auth"`
+	Upload  string `yaml:"upload"`
+	Gallery string `yaml:"gallery"`
+	// API 每个 API 密钥（或会话）调用需要认证的接口的总额度
+	API string `yaml:"api"`
+}
+
+// AccessControl 运维接口的访问控制：Bearer Token 或直连对端 IP 白名单（不看代理头），
+// 都为空时拒绝所有访问；unix socket 监听下只能使用 token
+type AccessControl struct {
+	Token      string   `yaml:"token"`
+	AllowedIPs []string `yaml:"allowed_ips"`
+}
+
+var current atomic.Pointer[ServerConfig]
+
+func init() {
+	current.Store(DefaultServerConfig())
+}
+
+// Current 返回当前生效的服务器配置，热加载后返回新配置。返回值只读。
+func Current() *ServerConfig {
+	return current.Load()
+}
+
+// DefaultServerConfig 未配置时使用的默认值
+func DefaultServerConfig() *ServerConfig {
+	cfg := &ServerConfig{}
+	cfg.Server.Listen = ":9090"
+	cfg.Server.ReadTimeout = Duration{15 * time.Second}
+	cfg.Server.WriteTimeout = Duration{60 * time.Second} // 60秒以支持大文件上传
+	cfg.Server.ShutdownTimeout = Duration{30 * time.Second}
+	cfg.Server.BodyLimitKB = 1024
+
+	cfg.TLS.AutocertCacheDir = "autocert"
+	cfg.Proxy.ClientIPHeaders = []string{"X-Forwarded-For", "X-Real-IP"}
+
+	cfg.Storage.MinFreeMB = 500
+
+	cfg.Jobs.Workers = 2
+	cfg.Jobs.PollInterval = Duration{2 * time.Second}
+
+	cfg.Maintenance.Interval = Duration{time.Hour}
+	cfg.Maintenance.TempFileMaxAge = Duration{24 * time.Hour}
+	cfg.Maintenance.JobRetention = Duration{7 * 24 * time.Hour}
+
+	cfg.HTTPCache.Gallery = "public, max-age=60"
+	cfg.HTTPCache.Images = "private, no-cache"
+
+	cfg.Compression = CompressionConfig{Enabled: true, GzipLevel: 5, BrotliLevel: 4, MinSize: 1024}
+
+	cfg.Log.Level = "info"
+	cfg.Log.Format = "json"
+
+	cfg.AccessLog = AccessLogConfig{
+		Output:     "stdout",
+		Format:     "json",
+		MaxSizeMB:  100,
+		MaxBackups: 7,
+		Exclude:    []string{"/healthz", "/readyz", "/metrics", "/assets/", "/favicon.svg"},
+	}
+	cfg.CORS = CORSConfig{
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "X-Request-ID"},
+		ExposedHeaders: []string{"X-Request-ID", "Retry-After", "X-Cache", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
+		MaxAge:         600,
+	}
+	cfg.RateLimit = RateLimitConfig{Auth: "10/m", Upload: "60/m", Gallery: "120/m", API: "3600/h"}
+	cfg.SMTP = SMTPConfig{Port: 587, TLS: "starttls"}
+	return cfg
+}
+
+// LoadServerConfig 依次应用默认值、配置文件（path 为空时尝试 ./config.yaml）、环境变量和
+// overrides（键为环境变量名，用于命令行参数），校验通过后设置为当前配置。
+// 相对路径都以启动时的工作目录为准，之后的 ReloadServerConfig 会重新读取同一来源。
+func LoadServerConfig(path string, overrides map[string]string) (*ServerConfig, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	src := configSource{path: path, explicit: path != "", baseDir: wd, overrides: overrides}
+	if !src.explicit {
+		src.path = "config.yaml"
+	}
+	if !filepath.IsAbs(src.path) {
+		src.path = filepath.Join(wd, src.path)
+	}
+
+	cfg, err := src.read()
+	if err != nil {
+		return nil, err
+	}
+	reloadMu.Lock()
+	source = src
+	reloadMu.Unlock()
+	current.Store(cfg)
+	return cfg, nil
+}
+
+// configSource 配置的来源，热加载时按同样的方式重新读取
+type configSource struct {
+	path      string
+	explicit  bool
+	baseDir   string
+	overrides map[string]string
+}
+
+func (src configSource) read() (*ServerConfig, error) {
+	cfg := DefaultServerConfig()
+
+	data, err := os.ReadFile(src.path)
+	switch {
+	case err == nil:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, i18n.NewError(i18n.ConfigParseFailed, src.path, err)
+		}
+	case src.explicit || !os.IsNotExist(err):
+		return nil, i18n.NewError(i18n.ConfigReadFailed, err)
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.applyOverrides(src.overrides); err != nil {
+		return nil, err
+	}
+	for _, dir := range []*string{&cfg.Server.DataDir, &cfg.Server.FrontendDir, &cfg.TLS.CertFile, &cfg.TLS.KeyFile} {
+		if *dir != "" && !filepath.IsAbs(*dir) {
+			*dir = filepath.Join(src.baseDir, *dir)
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// envOverride 环境变量名与对应的配置项
+type envOverride struct {
+	name string
+	set  func(string) error
+}
+
+func (cfg *ServerConfig) envOverrides() []envOverride {
+	str := func(dst *string) func(string) error {
+		return func(v string) error { *dst = strings.TrimSpace(v); return nil }
+	}
+	list := func(dst *[]string) func(string) error {
+		return func(v string) error { *dst = splitList(v); return nil }
+	}
+	integer := func(dst *int) func(string) error {
+		return func(v string) error {
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			*dst = n
+			return err
+		}
+	}
+	duration := func(dst *Duration) func(string) error {
+		return func(v string) error {
+			d, err := time.ParseDuration(strings.TrimSpace(v))
+			dst.Duration = d
+			return err
+		}
+	}
+
+	return []envOverride{
+		{"LISTEN_ADDR", str(&cfg.Server.Listen)},
+		{"READ_TIMEOUT", duration(&cfg.Server.ReadTimeout)},
+		{"WRITE_TIMEOUT", duration(&cfg.Server.WriteTimeout)},
+		{"SHUTDOWN_TIMEOUT", duration(&cfg.Server.ShutdownTimeout)},
+		{"FRONTEND_DIR", str(&cfg.Server.FrontendDir)},
+		{"DATA_DIR", str(&cfg.Server.DataDir)},
+		{"BODY_LIMIT_KB", func(v string) error {
+			n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			cfg.Server.BodyLimitKB = n
+			return err
+		}},
+
+		{"READ_ONLY", func(v string) error {
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			cfg.ReadOnly = b
+			return err
+		}},
+
+		{"TLS_CERT_FILE", str(&cfg.TLS.CertFile)},
+		{"TLS_KEY_FILE", str(&cfg.TLS.KeyFile)},
+		{"TLS_AUTOCERT_DOMAINS", list(&cfg.TLS.AutocertDomains)},
+		{"TLS_AUTOCERT_EMAIL", str(&cfg.TLS.AutocertEmail)},
+		{"TLS_AUTOCERT_CACHE_DIR", str(&cfg.TLS.AutocertCacheDir)},
+		{"TLS_REDIRECT_HTTP", str(&cfg.TLS.RedirectHTTP)},
+
+		{"TRUSTED_PROXIES", list(&cfg.Proxy.TrustedProxies)},
+		{"CLIENT_IP_HEADERS", list(&cfg.Proxy.ClientIPHeaders)},
+		{"TRUSTED_PLATFORM", str(&cfg.Proxy.Platform)},
+
+		{"LOG_LEVEL", str(&cfg.Log.Level)},
+		{"LOG_FORMAT", str(&cfg.Log.Format)},
+
+		{"HTTP_CACHE_GALLERY", str(&cfg.HTTPCache.Gallery)},
+		{"HTTP_CACHE_IMAGES", str(&cfg.HTTPCache.Images)},
+
+		{"COMPRESSION", func(v string) error {
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			cfg.Compression.Enabled = b
+			return err
+		}},
+		{"COMPRESSION_GZIP_LEVEL", integer(&cfg.Compression.GzipLevel)},
+		{"COMPRESSION_BROTLI_LEVEL", integer(&cfg.Compression.BrotliLevel)},
+		{"COMPRESSION_MIN_SIZE", integer(&cfg.Compression.MinSize)},
+
+		{"ACCESS_LOG", str(&cfg.AccessLog.Output)},
+		{"ACCESS_LOG_FORMAT", str(&cfg.AccessLog.Format)},
+		{"ACCESS_LOG_MAX_SIZE_MB", integer(&cfg.AccessLog.MaxSizeMB)},
+		{"ACCESS_LOG_ROTATE", str(&cfg.AccessLog.Rotate)},
+		{"ACCESS_LOG_MAX_BACKUPS", integer(&cfg.AccessLog.MaxBackups)},
+		{"ACCESS_LOG_EXCLUDE", list(&cfg.AccessLog.Exclude)},
+
+		{"CORS_ALLOWED_ORIGINS", list(&cfg.CORS.AllowedOrigins)},
+		{"CORS_ALLOWED_METHODS", list(&cfg.CORS.AllowedMethods)},
+		{"CORS_ALLOWED_HEADERS", list(&cfg.CORS.AllowedHeaders)},
+		{"CORS_EXPOSED_HEADERS", list(&cfg.CORS.ExposedHeaders)},
+		{"CORS_ALLOW_CREDENTIALS", func(v string) error {
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			cfg.CORS.AllowCredentials = b
+			return err
+		}},
+		{"CORS_MAX_AGE", integer(&cfg.CORS.MaxAge)},
+
+		{"RATE_LIMIT_AUTH", str(&cfg.RateLimit.Auth)},
+		{"RATE_LIMIT_UPLOAD", str(&cfg.RateLimit.Upload)},
+		{"RATE_LIMIT_GALLERY", str(&cfg.RateLimit.Gallery)},
+		{"RATE_LIMIT_API", str(&cfg.RateLimit.API)},
+
+		{"METRICS_TOKEN", str(&cfg.Metrics.Token)},
+		{"METRICS_ALLOWED_IPS", list(&cfg.Metrics.AllowedIPs)},
+		{"ADMIN_TOKEN", str(&cfg.Admin.Token)},
+		{"ADMIN_ALLOWED_IPS", list(&cfg.Admin.AllowedIPs)},
+
+		{"LOCAL_STORAGE_DIR", str(&cfg.Storage.LocalDir)},
+		{"LOCAL_STORAGE_MIN_FREE_MB", integer(&cfg.Storage.MinFreeMB)},
+
+		{"JOB_WORKERS", integer(&cfg.Jobs.Workers)},
+		{"JOB_POLL_INTERVAL", duration(&cfg.Jobs.PollInterval)},
+
+		{"MAINTENANCE_INTERVAL", duration(&cfg.Maintenance.Interval)},
+		{"MAINTENANCE_DRY_RUN", func(v string) error {
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			cfg.Maintenance.DryRun = b
+			return err
+		}},
+		{"MAINTENANCE_TEMP_FILE_MAX_AGE", duration(&cfg.Maintenance.TempFileMaxAge)},
+		{"MAINTENANCE_JOB_RETENTION", duration(&cfg.Maintenance.JobRetention)},
+
+		{"GITHUB_API_URL", str(&cfg.GitHub.APIURL)},
+		{"GITHUB_PROXY", str(&cfg.GitHub.Proxy)},
+		{"GITHUB_HEALTH_REPO", str(&cfg.GitHub.HealthRepo)},
+		{"GITHUB_TOKEN", str(&cfg.GitHub.Token)},
+		{"REDIS_URL", str(&cfg.Redis.URL)},
+
+		{"SMTP_HOST", str(&cfg.SMTP.Host)},
+		{"SMTP_PORT", integer(&cfg.SMTP.Port)},
+		{"SMTP_USERNAME", str(&cfg.SMTP.Username)},
+		{"SMTP_PASSWORD", str(&cfg.SMTP.Password)},
+		{"SMTP_FROM", str(&cfg.SMTP.From)},
+		{"SMTP_TLS", str(&cfg.SMTP.TLS)},
+
+		{"SENTRY_DSN", str(&cfg.ErrorReporting.SentryDSN)},
+		{"SENTRY_ENVIRONMENT", str(&cfg.ErrorReporting.SentryEnvironment)},
+		{"ERROR_WEBHOOK_URL", str(&cfg.ErrorReporting.WebhookURL)},
+	}
+}
+
+func (cfg *ServerConfig) applyEnv() error {
+	for _, o := range cfg.envOverrides() {
+		v, ok := os.LookupEnv(o.name)
+		if !ok {
+			continue
+		}
+		if err := o.set(v); err != nil {
+			return i18n.NewError(i18n.ConfigBadEnv, o.name, v)
+		}
+	}
+	return nil
+}
+
+// applyOverrides 应用命令行参数，参数按对应的环境变量名给出
+func (cfg *ServerConfig) applyOverrides(overrides map[string]string) error {
+	for _, o := range cfg.envOverrides() {
+		v, ok := overrides[o.name]
+		if !ok {
+			continue
+		}
+		if err := o.set(v); err != nil {
+			return i18n.NewError(i18n.ConfigBadFlag, o.name, v)
+		}
+	}
+	return nil
+}
+
+// Validate 校验配置，一次性返回所有错误
+func (cfg *ServerConfig) Validate() error {
+	var errs []error
+	fail := func(code i18n.Code, args ...interface{}) {
+		errs = append(errs, i18n.NewError(code, args...))
+	}
+
+	switch listen := cfg.Server.Listen; {
+	case listen == "systemd":
+	case strings.HasPrefix(listen, "unix:"):
+		if strings.TrimPrefix(listen, "unix:") == "" {
+			fail(i18n.ConfigNoSocketPath)
+		}
+	default:
+		if _, _, err := net.SplitHostPort(listen); err != nil {
+			fail(i18n.ConfigBadListen, listen)
+		}
+	}
+	if cfg.Server.ReadTimeout.Duration <= 0 || cfg.Server.WriteTimeout.Duration <= 0 || cfg.Server.ShutdownTimeout.Duration <= 0 {
+		fail(i18n.ConfigNotPositive, "server.read_timeout/write_timeout/shutdown_timeout")
+	}
+	if cfg.Server.BodyLimitKB < 0 {
+		fail(i18n.ConfigNegative, "server.body_limit_kb")
+	}
+
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		fail(i18n.ConfigTLSPair)
+	}
+	if cfg.TLS.CertFile != "" && len(cfg.TLS.AutocertDomains) > 0 {
+		fail(i18n.ConfigTLSExclusive)
+	}
+	if cfg.TLS.RedirectHTTP != "" {
+		if !cfg.TLS.Enabled() {
+			fail(i18n.ConfigTLSRedirect)
+		} else if _, _, err := net.SplitHostPort(cfg.TLS.RedirectHTTP); err != nil {
+			fail(i18n.ConfigBadAddr, "tls.redirect_http", cfg.TLS.RedirectHTTP)
+		}
+	}
+
+	for _, list := range []struct {
+		key     string
+		entries []string
+	}{
+		{"proxy.trusted_proxies", cfg.Proxy.TrustedProxies},
+		{"admin.allowed_ips", cfg.Admin.AllowedIPs},
+		{"metrics.allowed_ips", cfg.Metrics.AllowedIPs},
+	} {
+		for _, entry := range list.entries {
+			if net.ParseIP(entry) == nil {
+				if _, _, err := net.ParseCIDR(entry); err != nil {
+					fail(i18n.ConfigBadValue, list.key, entry)
+				}
+			}
+		}
+	}
+	if cfg.Proxy.Platform != "" && cfg.Proxy.PlatformHeader() == "" {
+		fail(i18n.ConfigNotOneOf, "proxy.platform", "cloudflare/google-app-engine/fly-io", cfg.Proxy.Platform)
+	}
+
+	switch strings.ToLower(cfg.Log.Level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		fail(i18n.ConfigNotOneOf, "log.level", "debug/info/warn/error", cfg.Log.Level)
+	}
+	switch strings.ToLower(cfg.Log.Format) {
+	case "json", "text":
+	default:
+		fail(i18n.ConfigNotOneOf, "log.format", "json/text", cfg.Log.Format)
+	}
+
+	if c := cfg.Compression; c.GzipLevel < 1 || c.GzipLevel > 9 || c.BrotliLevel < 0 || c.BrotliLevel > 11 || c.MinSize < 0 {
+		fail(i18n.ConfigCompression)
+	}
+
+	switch cfg.AccessLog.Format {
+	case "json", "combined":
+	default:
+		fail(i18n.ConfigNotOneOf, "access_log.format", "json/combined", cfg.AccessLog.Format)
+	}
+	switch cfg.AccessLog.Rotate {
+	case "", "hourly", "daily":
+	default:
+		fail(i18n.ConfigNotOneOf, "access_log.rotate", "hourly/daily", cfg.AccessLog.Rotate)
+	}
+
+	if cfg.Storage.MinFreeMB < 0 {
+		fail(i18n.ConfigNegative, "storage.min_free_mb")
+	}
+	if cfg.Jobs.Workers < 0 {
+		fail(i18n.ConfigNegative, "jobs.workers")
+	}
+	if cfg.Jobs.PollInterval.Duration <= 0 {
+		fail(i18n.ConfigNotPositive, "jobs.poll_interval")
+	}
+
+	// interval 为 0 表示不定时执行
+	if cfg.Maintenance.Interval.Duration < 0 {
+		fail(i18n.ConfigNegative, "maintenance.interval")
+	}
+	if cfg.Maintenance.TempFileMaxAge.Duration <= 0 || cfg.Maintenance.JobRetention.Duration <= 0 {
+		fail(i18n.ConfigNotPositive, "maintenance.temp_file_max_age/job_retention")
+	}
+
+	if cfg.SMTP.Enabled() {
+		if cfg.SMTP.Port < 1 || cfg.SMTP.Port > 65535 {
+			fail(i18n.ConfigBadValue, "smtp.port", strconv.Itoa(cfg.SMTP.Port))
+		}
+		if _, err := mail.ParseAddress(cfg.SMTP.From); err != nil {
+			fail(i18n.ConfigBadValue, "smtp.from", cfg.SMTP.From)
+		}
+		switch cfg.SMTP.TLS {
+		case "starttls", "tls", "none":
+		default:
+			fail(i18n.ConfigNotOneOf, "smtp.tls", "starttls/tls/none", cfg.SMTP.TLS)
+		}
+	}
+
+	if err := cfg.CORS.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	// 用切片而不是 map，保证错误按配置文件中的顺序输出
+	type field struct{ name, value string }
+	for _, f := range []field{
+		{"rate_limit.auth", cfg.RateLimit.Auth},
+		{"rate_limit.upload", cfg.RateLimit.Upload},
+		{"rate_limit.gallery", cfg.RateLimit.Gallery},
+		{"rate_limit.api", cfg.RateLimit.API},
+	} {
+		if _, err := ratelimit.ParseLimit(f.value); err != nil {
+			fail(i18n.ConfigField, f.name, err)
+		}
+	}
+
+	if repo := cfg.GitHub.HealthRepo; repo != "" {
+		owner, name, ok := strings.Cut(repo, "/")
+		if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			fail(i18n.ConfigBadValue, "github.health_repo", repo)
+		}
+		if cfg.GitHub.Token == "" {
+			fail(i18n.ConfigGitHubToken)
+		}
+	}
+
+	for _, f := range []field{
+		{"github.api_url", cfg.GitHub.APIURL},
+		{"github.proxy", cfg.GitHub.Proxy},
+		{"error_reporting.webhook_url", cfg.ErrorReporting.WebhookURL},
+	} {
+		if f.value == "" {
+			continue
+		}
+		if u, err := url.Parse(f.value); err != nil || u.Scheme == "" || u.Host == "" {
+			fail(i18n.ConfigBadValue, f.name, f.value)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate 检查跨域配置，禁止 "*" 与携带凭据同时使用
+func (c CORSConfig) Validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return i18n.NewError(i18n.CORSWildcardCredentials)
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			return i18n.NewError(i18n.CORSBadOrigin, origin)
+		}
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"pic/i18n"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// errorCodes 展开 errors.Join，返回其中每个 CodedError 的错误码
+func errorCodes(err error) []i18n.Code {
+	var codes []i18n.Code
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range joined.Unwrap() {
+			codes = append(codes, errorCodes(e)...)
+		}
+		return codes
+	}
+	var coded *i18n.CodedError
+	if errors.As(err, &coded) {
+		codes = append(codes, coded.Code)
+	}
+	return codes
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(*ServerConfig)
+		want   []i18n.Code
+	}{
+		{"defaults", func(*ServerConfig) {}, nil},
+		{"unix socket", func(c *ServerConfig) { c.Server.Listen = "unix:/run/pic.sock" }, nil},
+		{"unix socket without path", func(c *ServerConfig) { c.Server.Listen = "unix:" }, []i18n.Code{i18n.ConfigNoSocketPath}},
+		{"bad listen", func(c *ServerConfig) { c.Server.Listen = "9090" }, []i18n.Code{i18n.ConfigBadListen}},
+		{"zero timeout", func(c *ServerConfig) { c.Server.ReadTimeout = Duration{} }, []i18n.Code{i18n.ConfigNotPositive}},
+		{"tls pair", func(c *ServerConfig) { c.TLS.CertFile = "cert.pem" }, []i18n.Code{i18n.ConfigTLSPair}},
+		{"redirect without tls", func(c *ServerConfig) { c.TLS.RedirectHTTP = ":80" }, []i18n.Code{i18n.ConfigTLSRedirect}},
+		{"bad rate limit", func(c *ServerConfig) { c.RateLimit.API = "10/w" }, []i18n.Code{i18n.ConfigField}},
+		{"cors wildcard with credentials", func(c *ServerConfig) {
+			c.CORS.AllowedOrigins, c.CORS.AllowCredentials = []string{"*"}, true
+		}, []i18n.Code{i18n.CORSWildcardCredentials}},
+		{"cors origin with path", func(c *ServerConfig) { c.CORS.AllowedOrigins = []string{"https://a.example.com/app"} }, []i18n.Code{i18n.CORSBadOrigin}},
+		{"bad api url", func(c *ServerConfig) { c.GitHub.APIURL = "mirror.example.com" }, []i18n.Code{i18n.ConfigBadValue}},
+		{"health repo without token", func(c *ServerConfig) { c.GitHub.HealthRepo = "owner/repo" }, []i18n.Code{i18n.ConfigGitHubToken}},
+		{"bad health repo", func(c *ServerConfig) { c.GitHub.HealthRepo, c.GitHub.Token = "owner", "t" }, []i18n.Code{i18n.ConfigBadValue}},
+		{"bad trusted proxy", func(c *ServerConfig) { c.Proxy.TrustedProxies = []string{"10.0.0.0/33"} }, []i18n.Code{i18n.ConfigBadValue}},
+		{"bad admin allowed ip", func(c *ServerConfig) { c.Admin.AllowedIPs = []string{"127.0.0.1", "10.0.0.0/8x"} }, []i18n.Code{i18n.ConfigBadValue}},
+		{"bad metrics allowed ip", func(c *ServerConfig) { c.Metrics.AllowedIPs = []string{"192.168.1"} }, []i18n.Code{i18n.ConfigBadValue}},
+		{"ipv6 allowed ips", func(c *ServerConfig) {
+			c.Admin.AllowedIPs, c.Metrics.AllowedIPs = []string{"::1"}, []string{"fd00::/8"}
+		}, nil},
+		{"all errors reported", func(c *ServerConfig) {
+			c.Server.Listen = "9090"
+			c.Jobs.Workers = -1
+			c.SMTP.Host, c.SMTP.TLS = "smtp.example.com", "ssl"
+		}, []i18n.Code{i18n.ConfigBadListen, i18n.ConfigNegative, i18n.ConfigBadValue, i18n.ConfigNotOneOf}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultServerConfig()
+			tc.modify(cfg)
+			got := errorCodes(cfg.Validate())
+			slices.Sort(got)
+			want := slices.Clone(tc.want)
+			slices.Sort(want)
+			if !slices.Equal(got, want) {
+				t.Fatalf("错误码 = %v, 期望 %v（%v）", got, want, cfg.Validate())
+			}
+		})
+	}
+}
+
+// 多个字段出错时，错误按固定顺序输出，不随 map 遍历顺序变化
+func TestValidateOrder(t *testing.T) {
+	cfg := DefaultServerConfig()
+	cfg.RateLimit.Auth, cfg.RateLimit.Upload, cfg.RateLimit.Gallery, cfg.RateLimit.API = "x", "x", "x", "x"
+	cfg.GitHub.APIURL, cfg.GitHub.Proxy, cfg.ErrorReporting.WebhookURL = "x", "x", "x"
+	fields := []string{
+		"rate_limit.auth", "rate_limit.upload", "rate_limit.gallery", "rate_limit.api",
+		"github.api_url", "github.proxy", "error_reporting.webhook_url",
+	}
+
+	first := cfg.Validate().Error()
+	last := -1
+	for _, name := range fields {
+		i := strings.Index(first, name)
+		if i <= last {
+			t.Fatalf("%s 的位置不符合预期顺序：\n%s", name, first)
+		}
+		last = i
+	}
+	for range 20 {
+		if got := cfg.Validate().Error(); got != first {
+			t.Fatalf("两次校验的错误顺序不同：\n%s\n---\n%s", first, got)
+		}
+	}
+}
+
+// 优先级：默认值 < 配置文件 < 环境变量 < 命令行参数
+func TestConfigSources(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	file := write("config.yaml", "server:\n  listen: \":8000\"\n  read_timeout: 30s\n  data_dir: data\nlog:\n  level: debug\n")
+
+	for _, tc := range []struct {
+		name    string
+		src     configSource
+		env     map[string]string
+		wantErr i18n.Code
+		check   func(*ServerConfig) bool
+	}{
+		{"file", configSource{path: file, explicit: true, baseDir: dir}, nil, "", func(c *ServerConfig) bool {
+			return c.Server.Listen == ":8000" && c.Server.ReadTimeout.Duration == 30*time.Second &&
+				c.Server.DataDir == filepath.Join(dir, "data") && c.Log.Level == "debug" &&
+				c.Server.WriteTimeout.Duration == 60*time.Second
+		}},
+		{"env over file", configSource{path: file, explicit: true, baseDir: dir},
+			map[string]string{"LISTEN_ADDR": " :8001 ", "READ_TIMEOUT": "5s", "TRUSTED_PROXIES": "10.0.0.0/8, ,127.0.0.1"}, "",
+			func(c *ServerConfig) bool {
+				return c.Server.Listen == ":8001" && c.Server.ReadTimeout.Duration == 5*time.Second &&
+					slices.Equal(c.Proxy.TrustedProxies, []string{"10.0.0.0/8", "127.0.0.1"})
+			}},
+		{"flag over env", configSource{path: file, explicit: true, baseDir: dir, overrides: map[string]string{"LISTEN_ADDR": ":8002"}},
+			map[string]string{"LISTEN_ADDR": ":8001"}, "",
+			func(c *ServerConfig) bool { return c.Server.Listen == ":8002" }},
+		{"implicit file missing uses defaults", configSource{path: filepath.Join(dir, "missing.yaml"), baseDir: dir}, nil, "",
+			func(c *ServerConfig) bool { return c.Server.Listen == ":9090" }},
+		{"explicit file missing", configSource{path: filepath.Join(dir, "missing.yaml"), explicit: true, baseDir: dir}, nil, i18n.ConfigReadFailed, nil},
+		{"unknown field", configSource{path: write("unknown.yaml", "server:\n  listne: \":1\"\n"), explicit: true, baseDir: dir}, nil, i18n.ConfigParseFailed, nil},
+		{"bad duration", configSource{path: write("duration.yaml", "server:\n  read_timeout: soon\n"), explicit: true, baseDir: dir}, nil, i18n.ConfigParseFailed, nil},
+		{"bad env", configSource{path: file, explicit: true, baseDir: dir}, map[string]string{"JOB_WORKERS": "many"}, i18n.ConfigBadEnv, nil},
+		{"bad flag", configSource{path: file, explicit: true, baseDir: dir, overrides: map[string]string{"READ_ONLY": "maybe"}}, nil, i18n.ConfigBadFlag, nil},
+		{"env fails validation", configSource{path: file, explicit: true, baseDir: dir}, map[string]string{"LISTEN_ADDR": "8001"}, i18n.ConfigBadListen, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := tc.src.read()
+			if tc.wantErr != "" {
+				if codes := errorCodes(err); !slices.Contains(codes, tc.wantErr) {
+					t.Fatalf("错误 = %v（%v），期望 %s", err, codes, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.check(cfg) {
+				t.Fatalf("配置不符合预期: %+v", cfg)
+			}
+		})
+	}
+}
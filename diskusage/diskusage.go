@@ -0,0 +1,81 @@
+package diskusage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"pic/metrics"
+	"sync/atomic"
+	"time"
+)
+
+// ErrUnsupported 当前平台无法获取磁盘空间
+var ErrUnsupported = errors.New("当前平台不支持获取磁盘空间")
+
+// Usage 某个目录所在文件系统的空间
+type Usage struct {
+	Path       string    `json:"path"`
+	TotalBytes uint64    `json:"total_bytes"`
+	FreeBytes  uint64    `json:"free_bytes"`
+	UsedBytes  uint64    `json:"used_bytes"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Stat 读取 path 所在文件系统的空间，FreeBytes 为非 root 用户可用的空间，
+// UsedBytes 为实际已用的空间（root 保留块不计入已用）
+func Stat(path string) (Usage, error) {
+	total, free, avail, err := statfs(path)
+	if err != nil {
+		return Usage{}, err
+	}
+	return Usage{
+		Path:       path,
+		TotalBytes: total,
+		FreeBytes:  avail,
+		UsedBytes:  total - free,
+		CheckedAt:  time.Now(),
+	}, nil
+}
+
+var latest atomic.Pointer[Usage]
+
+// Latest 最近一次检查的结果，未启用监控或尚未检查成功时返回 false
+func Latest() (Usage, bool) {
+	if u := latest.Load(); u != nil {
+		return *u, true
+	}
+	return Usage{}, false
+}
+
+// Start 每隔 interval 检查一次 path 的剩余空间，更新 Latest 和指标
+func Start(ctx context.Context, path string, interval time.Duration) error {
+	if err := check(path); err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := check(path); err != nil {
+					slog.Warn("检查磁盘空间失败", "path", path, "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func check(path string) error {
+	u, err := Stat(path)
+	if err != nil {
+		return err
+	}
+	latest.Store(&u)
+	metrics.DiskFreeBytes.WithLabelValues(path).Set(float64(u.FreeBytes))
+	metrics.DiskTotalBytes.WithLabelValues(path).Set(float64(u.TotalBytes))
+	return nil
+}
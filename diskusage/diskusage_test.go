@@ -0,0 +1,23 @@
+package diskusage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStat(t *testing.T) {
+	u, err := Stat(t.TempDir())
+	if errors.Is(err, ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.TotalBytes == 0 || u.FreeBytes > u.TotalBytes || u.UsedBytes > u.TotalBytes {
+		t.Fatalf("磁盘空间不合理: %+v", u)
+	}
+	// root 保留块既不可用也不算已用，两者之和不超过总空间
+	if u.UsedBytes+u.FreeBytes > u.TotalBytes {
+		t.Fatalf("已用 %d + 可用 %d 超过总空间 %d", u.UsedBytes, u.FreeBytes, u.TotalBytes)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !freebsd
+
+package diskusage
+
+func statfs(string) (total, free, avail uint64, err error) {
+	return 0, 0, 0, ErrUnsupported
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin || freebsd
+
+package diskusage
+
+import "syscall"
+
+// statfs 返回总空间、空闲空间（含 root 保留块）和非 root 用户可用的空间
+func statfs(path string) (total, free, avail uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, 0, err
+	}
+	bsize := uint64(st.Bsize)
+	return uint64(st.Blocks) * bsize, uint64(st.Bfree) * bsize, uint64(st.Bavail) * bsize, nil
+}
@@ -0,0 +1,21 @@
+//go:build embedfrontend
+
+package frontend
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// 需要先构建前端（frontend/dist），再用 go build -tags embedfrontend 编译
+//
+//go:embed all:dist
+var dist embed.FS
+
+func init() {
+	sub, err := fs.Sub(dist, "dist")
+	if err != nil {
+		panic(err)
+	}
+	embedded = sub
+}
@@ -0,0 +1,35 @@
+package frontend
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultDir 未内嵌前端且未配置目录时使用的构建产物目录
+const DefaultDir = "frontend/dist"
+
+// embedded 编译时内嵌的前端资源，未使用 embedfrontend 标签编译时为 nil
+var embedded fs.FS
+
+// Embedded 二进制中是否内嵌了前端
+func Embedded() bool {
+	return embedded != nil
+}
+
+// Open 返回前端资源：dir 不为空时使用磁盘目录（开发时覆盖内嵌资源），
+// 否则使用内嵌资源，未内嵌时回退到 DefaultDir。
+// 目录在调用时转换为绝对路径，之后切换工作目录不受影响。
+func Open(dir string) (fs.FS, error) {
+	if dir == "" {
+		if embedded != nil {
+			return embedded, nil
+		}
+		dir = DefaultDir
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return os.DirFS(abs), nil
+}
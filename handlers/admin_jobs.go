@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"pic/i18n"
+	"pic/jobs"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListJobs 管理端：按状态/类型查看后台任务及各状态数量
+func ListJobs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	list, err := jobs.List(c.Request.Context(), c.Query("status"), c.Query("type"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.JobListFailed))
+		return
+	}
+	counts, err := jobs.Counts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.JobCountFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": list, "counts": counts})
+}
+
+// GetJob 管理端：任务详情
+func GetJob(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// RetryJob 管理端：重试失败的任务
+func RetryJob(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+	if err := jobs.Retry(c.Request.Context(), job.ID); err != nil {
+		if errors.Is(err, jobs.ErrNotRetryable) {
+			c.JSON(http.StatusConflict, i18n.Error(c, i18n.JobNotRetryable))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.JobRetryFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Text(c, i18n.JobRequeued)})
+}
+
+// DeleteJob 管理端：删除未在执行的任务
+func DeleteJob(c *gin.Context) {
+	job, ok := findJob(c)
+	if !ok {
+		return
+	}
+	if err := jobs.Delete(c.Request.Context(), job.ID); err != nil {
+		if errors.Is(err, jobs.ErrRunning) {
+			c.JSON(http.StatusConflict, i18n.Error(c, i18n.JobRunning))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.JobDeleteFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Text(c, i18n.JobDeleted)})
+}
+
+func findJob(c *gin.Context) (*jobs.Job, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.JobIDInvalid))
+		return nil, false
+	}
+	job, err := jobs.Get(c.Request.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, i18n.Error(c, i18n.JobNotFound))
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.JobGetFailed))
+		return nil, false
+	}
+	return job, true
+}
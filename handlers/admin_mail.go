@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"pic/i18n"
+	"pic/mailer"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SendTestMail 管理端：用当前 SMTP 配置发送一封测试邮件
+func SendTestMail(c *gin.Context) {
+	var req struct {
+		To string `json:"to" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.MailRecipientMissing))
+		return
+	}
+
+	err := mailer.Send(c.Request.Context(), req.To, mailer.TemplateTest, map[string]interface{}{
+		"Time": time.Now().Format("2006-01-02 15:04:05 MST"),
+	})
+	var coded *i18n.CodedError
+	errors.As(err, &coded)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": i18n.Text(c, i18n.MailTestSent)})
+	case errors.Is(err, mailer.ErrDisabled):
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.SMTPDisabled))
+	case coded != nil && coded.Code == i18n.MailBadRecipient:
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.MailSendFailed, err))
+	case coded != nil && coded.Code == i18n.MailSMTPError:
+		// 只有 SMTP 服务器连接或投递失败才是上游错误
+		c.JSON(http.StatusBadGateway, i18n.Error(c, i18n.MailSendFailed, err))
+	default:
+		// smtp.from 格式错误、模板缺失或渲染失败等服务端配置问题
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.MailSendFailed, err))
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"pic/i18n"
+	"pic/maintenance"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RunMaintenance 管理端：立即执行清理，dry_run=true 时只统计不删除
+func RunMaintenance(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.InvalidParameter, "dry_run"))
+		return
+	}
+	c.JSON(http.StatusOK, maintenance.Run(c.Request.Context(), dryRun))
+}
+
+// GetMaintenanceReport 管理端：最近一次清理报告
+func GetMaintenanceReport(c *gin.Context) {
+	report := maintenance.Last()
+	if report == nil {
+		c.JSON(http.StatusNotFound, i18n.Error(c, i18n.MaintenanceNotRun))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
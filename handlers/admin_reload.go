@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"pic/cluster"
+	"pic/config"
+	"pic/i18n"
+	"pic/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReloadConfig 重新加载服务器配置文件和环境变量，与发送 SIGHUP 相同。
+// 多实例部署时同时通知其它实例各自重新加载，返回的 restart_required 只反映当前实例。
+func ReloadConfig(c *gin.Context) {
+	restartRequired, err := config.ReloadServerConfig()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.ConfigInvalid, err))
+		return
+	}
+	if err := cluster.Publish(c.Request.Context(), "config-reload", ""); err != nil {
+		logger.FromContext(c.Request.Context()).Warn("通知其它实例重新加载配置失败", "error", err)
+	}
+	if restartRequired == nil {
+		restartRequired = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Text(c, i18n.ConfigReloaded), "restart_required": restartRequired})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"pic/i18n"
+	"pic/settings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSystemSettings 获取系统设置
+func GetSystemSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, settings.Get())
+}
+
+// UpdateSystemSettings 修改系统设置，请求体中未出现的字段保持不变
+func UpdateSystemSettings(c *gin.Context) {
+	// 在取得设置的更新锁之前读完请求体，慢速客户端不会阻塞其它修改
+	var patch map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.InvalidRequest))
+		return
+	}
+	raw, _ := json.Marshal(patch)
+
+	var decodeErr error
+	err := settings.Patch(func(s *settings.Settings) error {
+		decodeErr = json.Unmarshal(raw, s)
+		return decodeErr
+	})
+	var coded *i18n.CodedError
+	switch {
+	case err == nil:
+	case decodeErr != nil:
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.InvalidRequest))
+		return
+	case errors.As(err, &coded):
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.ValidationFailed, err))
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.SaveSettingsFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Text(c, i18n.SettingsSaved), "settings": settings.Get()})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pic/settings"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func putSettings(body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/settings", UpdateSystemSettings)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPut, "/settings", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpdateSystemSettings(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := settings.Init(ctx, newTestDB(t)); err != nil {
+		t.Fatal(err)
+	}
+	before := settings.Get()
+	before.AllowedFileTypes = slices.Clone(before.AllowedFileTypes)
+	if len(before.AllowedFileTypes) < 2 {
+		t.Fatalf("默认文件类型太少: %v", before.AllowedFileTypes)
+	}
+
+	// 与当前列表等长但包含非法项：校验失败，当前设置不能被改动
+	invalid := make([]string, len(before.AllowedFileTypes))
+	for i := range invalid {
+		invalid[i] = `"x"`
+	}
+	invalid[0] = `"bad.ext"`
+	w := putSettings(`{"allowed_file_types": [` + strings.Join(invalid, ",") + `]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("非法设置应返回 400，实际 %d: %s", w.Code, w.Body)
+	}
+	if got := settings.Get(); !reflect.DeepEqual(got, before) {
+		t.Fatalf("校验失败后设置被修改:\n got %+v\nwant %+v", got, before)
+	}
+
+	// 字段类型错误在应用到当前设置时才发现，同样返回 400 且不做修改
+	if w := putSettings(`{"public_gallery_enabled": false, "max_upload_size_mb": "big"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("类型错误应返回 400，实际 %d: %s", w.Code, w.Body)
+	}
+	if got := settings.Get(); !reflect.DeepEqual(got, before) {
+		t.Fatalf("类型错误后设置被修改:\n got %+v\nwant %+v", got, before)
+	}
+
+	// 等长的合法修改要写入数据库，重新加载后仍然生效
+	valid := make([]string, len(before.AllowedFileTypes))
+	want := make([]string, len(valid))
+	for i := range valid {
+		want[i] = string(rune('a'+i)) + "ext"
+		valid[i] = `".` + strings.ToUpper(want[i]) + `"`
+	}
+	if w := putSettings(`{"allowed_file_types": [` + strings.Join(valid, ",") + `]}`); w.Code != http.StatusOK {
+		t.Fatalf("合法设置应返回 200，实际 %d: %s", w.Code, w.Body)
+	}
+	if err := settings.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := settings.Get().AllowedFileTypes; !reflect.DeepEqual(got, want) {
+		t.Fatalf("重新加载后文件类型为 %v，期望 %v", got, want)
+	}
+}
+
+func TestUpdateSystemSettingsMergesLatest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := newTestDB(t)
+	if err := settings.Init(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟其它实例修改了设置，而本实例的缓存尚未同步
+	row := settings.SystemSetting{Key: "max_upload_size_mb", Value: "7"}
+	if err := db.Create(&row).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := settings.Get().MaxUploadSizeMB; got == 7 {
+		t.Fatal("缓存不应已同步")
+	}
+
+	if w := putSettings(`{"public_gallery_enabled": false}`); w.Code != http.StatusOK {
+		t.Fatalf("合法设置应返回 200，实际 %d: %s", w.Code, w.Body)
+	}
+	if err := settings.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	got := settings.Get()
+	if got.MaxUploadSizeMB != 7 || got.PublicGalleryEnabled {
+		t.Fatalf("部分修改覆盖了其它实例的修改: %+v", got)
+	}
+}
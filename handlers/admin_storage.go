@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"pic/config"
+	"pic/diskusage"
+	"pic/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// diskUsage 最近一次磁盘空间检查的结果，测试中替换
+var diskUsage = diskusage.Latest
+
+// GetDiskUsage 管理端：本地存储目录所在磁盘的空间及上传阈值
+func GetDiskUsage(c *gin.Context) {
+	u, ok := diskUsage()
+	if !ok {
+		c.JSON(http.StatusNotFound, i18n.Error(c, i18n.DiskMonitorDisabled))
+		return
+	}
+	minFree := uint64(config.Current().Storage.MinFreeMB) << 20
+	c.JSON(http.StatusOK, gin.H{
+		"usage":          u,
+		"min_free_bytes": minFree,
+		"low":            minFree > 0 && u.FreeBytes < minFree,
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pic/diskusage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetDiskUsage(t *testing.T) {
+	defer func(old func() (diskusage.Usage, bool)) { diskUsage = old }(diskUsage)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/storage", GetDiskUsage)
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/storage", nil))
+		return w
+	}
+
+	// 未启用监控或尚未检查成功
+	diskUsage = func() (diskusage.Usage, bool) { return diskusage.Usage{}, false }
+	if w := get(); w.Code != http.StatusNotFound {
+		t.Fatalf("未检查时状态码 %d: %s", w.Code, w.Body)
+	}
+
+	// 默认配置 storage.min_free_mb 为 500
+	for _, tc := range []struct {
+		name   string
+		freeMB uint64
+		low    bool
+	}{
+		{"enough space", 600, false},
+		{"low space", 100, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			u := diskusage.Usage{Path: "/data", TotalBytes: 1000 << 20, FreeBytes: tc.freeMB << 20, UsedBytes: 900 << 20}
+			diskUsage = func() (diskusage.Usage, bool) { return u, true }
+			w := get()
+			var body struct {
+				Usage        diskusage.Usage `json:"usage"`
+				MinFreeBytes uint64          `json:"min_free_bytes"`
+				Low          bool            `json:"low"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != http.StatusOK || body.Usage.FreeBytes != u.FreeBytes || body.Usage.UsedBytes != u.UsedBytes ||
+				body.MinFreeBytes != 500<<20 || body.Low != tc.low {
+				t.Fatalf("状态码 %d, 响应 %s", w.Code, w.Body)
+			}
+		})
+	}
+}
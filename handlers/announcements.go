@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"pic/announcement"
+	"pic/i18n"
+	"pic/middleware"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetPublicAnnouncements 公开图库访客可见的公告
+func GetPublicAnnouncements(c *gin.Context) {
+	listAnnouncements(c, announcement.AudiencePublic)
+}
+
+// GetUserAnnouncements 登录用户可见的公告
+func GetUserAnnouncements(c *gin.Context) {
+	listAnnouncements(c, announcement.AudienceUsers)
+}
+
+func listAnnouncements(c *gin.Context, audience string) {
+	list, err := announcement.Active(audience, middleware.UserOrSessionKey(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.AnnouncementGetFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"announcements": list})
+}
+
+// DismissAnnouncement 当前用户关闭公告，公告修改后会重新显示
+func DismissAnnouncement(c *gin.Context) {
+	owner := middleware.UserOrSessionKey(c)
+	if owner == "" {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.InvalidRequest))
+		return
+	}
+	a, ok := findAnnouncement(c)
+	if !ok {
+		return
+	}
+	if !a.Dismissible {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.AnnouncementNotDismissible))
+		return
+	}
+	if err := announcement.Dismiss(owner, a); err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.AnnouncementDismissFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Text(c, i18n.AnnouncementDismissed)})
+}
+
+// AnnouncementRequest 发布/修改公告的请求，只包含管理员可以编辑的字段
+type AnnouncementRequest struct {
+	Title       string     `json:"title" binding:"required"`
+	Content     string     `json:"content"`
+	Level       string     `json:"level"`
+	Audience    string     `json:"audience"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Dismissible bool       `json:"dismissible"`
+}
+
+func newAnnouncementRequest(a *announcement.Announcement) AnnouncementRequest {
+	return AnnouncementRequest{
+		Title:       a.Title,
+		Content:     a.Content,
+		Level:       a.Level,
+		Audience:    a.Audience,
+		StartsAt:    a.StartsAt,
+		EndsAt:      a.EndsAt,
+		Dismissible: a.Dismissible,
+	}
+}
+
+// apply 把请求中的字段写入公告
+func (r AnnouncementRequest) apply(a *announcement.Announcement) {
+	a.Title, a.Content, a.Level, a.Audience = r.Title, r.Content, r.Level, r.Audience
+	a.StartsAt, a.EndsAt, a.Dismissible = r.StartsAt, r.EndsAt, r.Dismissible
+}
+
+// ListAnnouncements 管理端：全部公告
+func ListAnnouncements(c *gin.Context) {
+	list, err := announcement.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.AnnouncementGetFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"announcements": list})
+}
+
+// CreateAnnouncement 管理端：发布公告
+func CreateAnnouncement(c *gin.Context) {
+	var req AnnouncementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.InvalidRequest))
+		return
+	}
+	var a announcement.Announcement
+	req.apply(&a)
+	if err := a.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.ValidationFailed, err))
+		return
+	}
+	if err := announcement.Create(&a); err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.AnnouncementCreateFailed))
+		return
+	}
+	c.JSON(http.StatusCreated, a)
+}
+
+// UpdateAnnouncement 管理端：修改公告，未出现的字段保持不变
+func UpdateAnnouncement(c *gin.Context) {
+	a, ok := findAnnouncement(c)
+	if !ok {
+		return
+	}
+	// 以当前值为基础解析请求，未出现的字段保持不变
+	req := newAnnouncementRequest(a)
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.InvalidRequest))
+		return
+	}
+	req.apply(a)
+	if err := a.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.ValidationFailed, err))
+		return
+	}
+	if err := announcement.Save(a); err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.AnnouncementSaveFailed))
+		return
+	}
+	c.JSON(http.StatusOK, a)
+}
+
+// DeleteAnnouncement 管理端：删除公告
+func DeleteAnnouncement(c *gin.Context) {
+	a, ok := findAnnouncement(c)
+	if !ok {
+		return
+	}
+	if err := announcement.Delete(a.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.AnnouncementDeleteFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": i18n.Text(c, i18n.AnnouncementDeleted)})
+}
+
+func findAnnouncement(c *gin.Context) (*announcement.Announcement, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.AnnouncementIDInvalid))
+		return nil, false
+	}
+	a, err := announcement.Get(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, i18n.Error(c, i18n.AnnouncementNotFound))
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.AnnouncementGetFailed))
+		return nil, false
+	}
+	return a, true
+}
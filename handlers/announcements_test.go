@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pic/announcement"
+	"pic/middleware"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDismissAnnouncement(t *testing.T) {
+	db := newTestDB(t)
+	if err := announcement.Init(db); err != nil {
+		t.Fatal(err)
+	}
+	a := announcement.Announcement{Title: "maintenance", Dismissible: true}
+	fixed := announcement.Announcement{Title: "policy"}
+	for _, item := range []*announcement.Announcement{&a, &fixed} {
+		if err := announcement.Create(item); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if user := c.GetHeader("X-Test-User"); user != "" {
+			c.Set(middleware.UserIDKey, user)
+		}
+	})
+	r.GET("/announcements/current", GetUserAnnouncements)
+	r.POST("/announcements/:id/dismiss", DismissAnnouncement)
+
+	do := func(method, path, user, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	visible := func(user, auth string) map[uint]bool {
+		t.Helper()
+		w := do(http.MethodGet, "/announcements/current", user, auth)
+		var body struct {
+			Announcements []announcement.Announcement `json:"announcements"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("获取公告失败 %d: %s", w.Code, w.Body)
+		}
+		ids := map[uint]bool{}
+		for _, item := range body.Announcements {
+			ids[item.ID] = true
+		}
+		return ids
+	}
+	dismiss := func(id uint, user, auth string) int {
+		return do(http.MethodPost, "/announcements/"+itoa(id)+"/dismiss", user, auth).Code
+	}
+
+	if code := dismiss(a.ID, "1", ""); code != http.StatusOK {
+		t.Fatalf("关闭公告返回 %d", code)
+	}
+	if code := dismiss(a.ID, "1", ""); code != http.StatusOK {
+		t.Fatalf("重复关闭返回 %d", code)
+	}
+	if code := dismiss(fixed.ID, "1", ""); code != http.StatusBadRequest {
+		t.Fatalf("关闭不可关闭的公告返回 %d, 期望 400", code)
+	}
+	if code := dismiss(a.ID, "", ""); code != http.StatusBadRequest {
+		t.Fatalf("没有用户和令牌时返回 %d, 期望 400", code)
+	}
+	if code := dismiss(a.ID, "", "Bearer abc"); code != http.StatusOK {
+		t.Fatalf("按令牌关闭返回 %d", code)
+	}
+
+	for _, tc := range []struct {
+		name       string
+		user, auth string
+		want       bool
+	}{
+		{"dismissed by user", "1", "", false},
+		{"another user", "2", "", true},
+		{"dismissed by token", "", "Bearer abc", false},
+		{"another token", "", "Bearer xyz", true},
+	} {
+		if got := visible(tc.user, tc.auth); got[a.ID] != tc.want || !got[fixed.ID] {
+			t.Errorf("%s: 可见公告 %v，期望公告 %d 可见=%v", tc.name, got, a.ID, tc.want)
+		}
+	}
+
+	// 公告修改后版本变化，重新显示
+	if err := announcement.Save(&a); err != nil {
+		t.Fatal(err)
+	}
+	if got := visible("1", ""); !got[a.ID] {
+		t.Fatalf("公告修改后仍被隐藏: %v", got)
+	}
+	if code := dismiss(a.ID, "1", ""); code != http.StatusOK {
+		t.Fatalf("再次关闭返回 %d", code)
+	}
+	if got := visible("1", ""); got[a.ID] {
+		t.Fatalf("关闭新版本后仍然可见: %v", got)
+	}
+
+	// 取消可关闭后不能再关闭；删除公告时一并删除关闭记录
+	a.Dismissible = false
+	if err := announcement.Save(&a); err != nil {
+		t.Fatal(err)
+	}
+	if code := dismiss(a.ID, "1", ""); code != http.StatusBadRequest {
+		t.Fatalf("关闭不可关闭的公告返回 %d", code)
+	}
+	if err := announcement.Delete(a.ID); err != nil {
+		t.Fatal(err)
+	}
+	var left int64
+	if err := db.Model(&announcement.Dismissal{}).Where("announcement_id = ?", a.ID).Count(&left).Error; err != nil || left != 0 {
+		t.Fatalf("删除公告后剩余 %d 条关闭记录: %v", left, err)
+	}
+}
+
+// 请求体中 id、version、created_at 等非编辑字段被忽略，修改时未出现的字段保持不变
+func TestAnnouncementWriteFields(t *testing.T) {
+	if err := announcement.Init(newTestDB(t)); err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/announcements", CreateAnnouncement)
+	r.PUT("/announcements/:id", UpdateAnnouncement)
+	do := func(method, path, body string) announcement.Announcement {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+			t.Fatalf("%s %s 返回 %d: %s", method, path, w.Code, w.Body)
+		}
+		var a announcement.Announcement
+		if err := json.Unmarshal(w.Body.Bytes(), &a); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	forged := `"id": 999, "version": 50, "created_at": "2000-01-01T00:00:00Z", "updated_at": "2000-01-01T00:00:00Z"`
+	created := do(http.MethodPost, "/announcements", `{"title": "hello", "dismissible": true, `+forged+`}`)
+	if created.ID == 999 || created.Version == 50 || created.CreatedAt.Year() == 2000 || created.UpdatedAt.Year() == 2000 {
+		t.Fatalf("发布时写入了非编辑字段: %+v", created)
+	}
+
+	updated := do(http.MethodPut, "/announcements/"+itoa(created.ID), `{"content": "body", `+forged+`}`)
+	stored, err := announcement.Get(created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range []*announcement.Announcement{&updated, stored} {
+		if a.ID != created.ID || a.Version == 50 || a.CreatedAt.Year() == 2000 || a.UpdatedAt.Year() == 2000 {
+			t.Fatalf("修改时写入了非编辑字段: %+v", a)
+		}
+		if a.Title != "hello" || a.Content != "body" || !a.Dismissible {
+			t.Fatalf("修改后字段不正确: %+v", a)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http/pprof"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MountDebug 在给定路由组下挂载 pprof 和 expvar，调用方负责加上管理员认证
+func MountDebug(rg *gin.RouterGroup) {
+	debug := rg.Group("/debug")
+	debug.GET("/vars", gin.WrapH(expvar.Handler()))
+
+	debug.GET("/pprof/", gin.WrapF(pprof.Index))
+	debug.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		debug.GET("/pprof/"+name, gin.WrapH(pprof.Handler(name)))
+	}
+}
@@ -0,0 +1,497 @@
+package handlers
+
+import (
+	"go/token"
+	"net/http"
+	"pic/announcement"
+	"pic/diskusage"
+	"pic/i18n"
+	"pic/jobs"
+	"pic/maintenance"
+	"pic/middleware"
+	"pic/settings"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+)
+
+// 接口的认证方式，对应 components.securitySchemes 中的名称
+const (
+	authUser  = "bearerAuth" // 登录返回的用户访问令牌
+	authAdmin = "adminToken" // 服务器配置中的 admin.token
+)
+
+// routeDoc 描述单个接口在 OpenAPI 文档中的信息
+type routeDoc struct {
+	Tag     string
+	Summary string
+	// Auth 认证方式（authUser/authAdmin），为空表示无需认证
+	Auth string
+	// Request 为 JSON 请求体的类型（传零值即可），按字段的 json 和 binding 标签生成 schema
+	Request interface{}
+	// Response 为成功响应体的类型，Status 为成功状态码（默认 200）
+	Response interface{}
+	Status   int
+	// Produces 为非 JSON 响应的 Content-Type，如二维码图片
+	Produces []string
+	Query    []string
+}
+
+// 只用于文档的响应类型，字段与对应处理器返回的 gin.H 一致
+
+type messageDoc struct {
+	Message string `json:"message"`
+}
+
+type announcementListDoc struct {
+	Announcements []announcement.Announcement `json:"announcements"`
+}
+
+type jobListDoc struct {
+	Jobs   []jobs.Job       `json:"jobs"`
+	Counts map[string]int64 `json:"counts"`
+}
+
+type settingsSavedDoc struct {
+	Message  string            `json:"message"`
+	Settings settings.Settings `json:"settings"`
+}
+
+type reloadDoc struct {
+	Message         string   `json:"message"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+type diskUsageDoc struct {
+	Usage        diskusage.Usage `json:"usage"`
+	MinFreeBytes uint64          `json:"min_free_bytes"`
+	Low          bool            `json:"low"`
+}
+
+type rateLimitDoc struct {
+	Limits map[string]middleware.RateLimitState `json:"limits"`
+}
+
+type createRepositoryDoc struct {
+	Message  string   `json:"message"`
+	Owner    string   `json:"owner"`
+	Name     string   `json:"name"`
+	FullName string   `json:"full_name"`
+	Private  bool     `json:"private"`
+	Branch   string   `json:"branch"`
+	HTMLURL  string   `json:"html_url"`
+	PagesURL string   `json:"pages_url"`
+	Warnings []string `json:"warnings"`
+}
+
+type mailTestDoc struct {
+	To string `json:"to" binding:"required"`
+}
+
+// apiRouteDocs 以 "METHOD 路径" 为键记录接口说明，新增路由时在这里补充。
+// 登录、注册、配置和图片等接口的处理器未声明响应类型，文档中只给出说明。
+var apiRouteDocs = map[string]routeDoc{
+	"POST /api/auth/login":                {Tag: "auth", Summary: "用户登录，返回访问令牌"},
+	"POST /api/auth/register":             {Tag: "auth", Summary: "注册新用户"},
+	"GET /api/gallery/:slug":              {Tag: "gallery", Summary: "获取公开图库"},
+	"GET /api/gallery/check-slug":         {Tag: "gallery", Summary: "检查图库短链接是否可用", Auth: authUser, Query: []string{"slug"}},
+	"GET /api/github/repos":               {Tag: "github", Summary: "列出 GitHub 仓库", Auth: authUser},
+	"POST /api/github/repos":              {Tag: "github", Summary: "创建新的 GitHub 图床仓库", Auth: authUser, Request: CreateRepositoryRequest{}, Response: createRepositoryDoc{}, Status: http.StatusCreated},
+	"POST /api/github/verify-token":       {Tag: "github", Summary: "验证 GitHub Token", Auth: authUser},
+	"GET /api/config":                     {Tag: "config", Summary: "获取用户配置", Auth: authUser},
+	"POST /api/config":                    {Tag: "config", Summary: "保存用户配置", Auth: authUser},
+	"POST /api/upload":                    {Tag: "images", Summary: "上传图片（multipart/form-data）", Auth: authUser},
+	"GET /api/images":                     {Tag: "images", Summary: "获取图片列表", Auth: authUser},
+	"DELETE /api/images/:id":              {Tag: "images", Summary: "删除图片", Auth: authUser},
+	"GET /api/rate-limit":                 {Tag: "auth", Summary: "查询当前 API 密钥的剩余调用额度", Auth: authUser, Response: rateLimitDoc{}},
+	"GET /api/qrcode":                     {Tag: "images", Summary: "生成图片、分享链接或图库地址的二维码（PNG/SVG）", Auth: authUser, Query: []string{"url", "format", "size", "level"}, Produces: []string{"image/png", "image/svg+xml"}},
+	"POST /api/admin/reload":              {Tag: "admin", Summary: "重新加载服务器配置（同 SIGHUP）", Auth: authAdmin, Response: reloadDoc{}},
+	"GET /api/admin/settings":             {Tag: "admin", Summary: "获取系统设置", Auth: authAdmin, Response: settings.Settings{}},
+	"PUT /api/admin/settings":             {Tag: "admin", Summary: "修改系统设置（部分更新）", Auth: authAdmin, Request: settings.Settings{}, Response: settingsSavedDoc{}},
+	"GET /api/announcements":              {Tag: "announcements", Summary: "公开图库访客可见的公告", Response: announcementListDoc{}},
+	"GET /api/announcements/current":      {Tag: "announcements", Summary: "登录用户可见的公告", Auth: authUser, Response: announcementListDoc{}},
+	"POST /api/announcements/:id/dismiss": {Tag: "announcements", Summary: "关闭公告，公告修改后重新显示", Auth: authUser, Response: messageDoc{}},
+	"GET /api/admin/announcements":        {Tag: "admin", Summary: "全部公告", Auth: authAdmin, Response: announcementListDoc{}},
+	"POST /api/admin/announcements":       {Tag: "admin", Summary: "发布公告", Auth: authAdmin, Request: AnnouncementRequest{}, Response: announcement.Announcement{}, Status: http.StatusCreated},
+	"PUT /api/admin/announcements/:id":    {Tag: "admin", Summary: "修改公告", Auth: authAdmin, Request: AnnouncementRequest{}, Response: announcement.Announcement{}},
+	"DELETE /api/admin/announcements/:id": {Tag: "admin", Summary: "删除公告", Auth: authAdmin, Response: messageDoc{}},
+	"GET /api/admin/jobs":                 {Tag: "admin", Summary: "后台任务列表及各状态数量", Auth: authAdmin, Query: []string{"status", "type", "limit"}, Response: jobListDoc{}},
+	"GET /api/admin/jobs/:id":             {Tag: "admin", Summary: "后台任务详情", Auth: authAdmin, Response: jobs.Job{}},
+	"POST /api/admin/jobs/:id/retry":      {Tag: "admin", Summary: "重试失败的后台任务", Auth: authAdmin, Response: messageDoc{}},
+	"DELETE /api/admin/jobs/:id":          {Tag: "admin", Summary: "删除后台任务", Auth: authAdmin, Response: messageDoc{}},
+	"GET /api/admin/storage/disk":         {Tag: "admin", Summary: "本地存储磁盘空间", Auth: authAdmin, Response: diskUsageDoc{}},
+	"POST /api/admin/mail/test":           {Tag: "admin", Summary: "用当前 SMTP 配置发送测试邮件", Auth: authAdmin, Request: mailTestDoc{}, Response: messageDoc{}},
+	"POST /api/admin/maintenance/run":     {Tag: "admin", Summary: "立即执行清理（dry_run=true 时只生成报告）", Auth: authAdmin, Query: []string{"dry_run"}, Response: maintenance.Report{}},
+	"GET /api/admin/maintenance/report":   {Tag: "admin", Summary: "最近一次清理报告", Auth: authAdmin, Response: maintenance.Report{}},
+	"GET /api/docs":                       {Tag: "docs", Summary: "Swagger UI", Produces: []string{"text/html"}},
+	"GET /api/docs/openapi.json":          {Tag: "docs", Summary: "OpenAPI 文档"},
+	"GET /api/docs/assets/:file":          {Tag: "docs", Summary: "Swagger UI 静态资源", Produces: []string{"text/css", "application/javascript"}},
+}
+
+// OpenAPISpec 根据已注册的路由生成 OpenAPI 3 文档，结果在首次请求时缓存
+func OpenAPISpec(r *gin.Engine) gin.HandlerFunc {
+	var (
+		once sync.Once
+		spec gin.H
+	)
+	return func(c *gin.Context) {
+		once.Do(func() { spec = buildOpenAPISpec(r.Routes()) })
+		c.JSON(http.StatusOK, spec)
+	}
+}
+
+// SwaggerUI 返回加载 /api/docs/openapi.json 的 Swagger UI 页面
+func SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// swaggerAssets Swagger UI 的静态资源，随二进制分发，文档页不依赖外部 CDN
+var swaggerAssets = map[string]struct {
+	contentType string
+	body        []byte
+}{
+	"swagger-ui.css":       {"text/css; charset=utf-8", swaggerFiles.FileSwaggerUICSS},
+	"swagger-ui-bundle.js": {"application/javascript; charset=utf-8", swaggerFiles.FileSwaggerUIBundleJs},
+}
+
+// SwaggerAsset 返回 Swagger UI 页面引用的 CSS 和 JS
+func SwaggerAsset(c *gin.Context) {
+	asset, ok := swaggerAssets[c.Param("file")]
+	if !ok {
+		c.JSON(http.StatusNotFound, i18n.Error(c, i18n.APINotFound))
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, asset.contentType, asset.body)
+}
+
+func buildOpenAPISpec(routes gin.RoutesInfo) gin.H {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	schemas := newSchemaRegistry()
+	schemas.schemas["Error"] = gin.H{
+		"type": "object",
+		"properties": gin.H{
+			"error": gin.H{"type": "string", "description": "按 Accept-Language（zh/en）翻译的错误信息"},
+			"code":  gin.H{"type": "string", "description": "错误码，不随语言变化"},
+		},
+	}
+	paths := gin.H{}
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		doc, ok := apiRouteDocs[route.Method+" "+route.Path]
+		if !ok {
+			doc = routeDoc{Tag: strings.SplitN(strings.TrimPrefix(route.Path, "/api/"), "/", 2)[0]}
+		}
+
+		openAPIPath, pathParams := toOpenAPIPath(route.Path)
+		item, _ := paths[openAPIPath].(gin.H)
+		if item == nil {
+			item = gin.H{}
+			paths[openAPIPath] = item
+		}
+		item[strings.ToLower(route.Method)] = buildOperation(doc, pathParams, schemas)
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   "Pic 图床 API",
+			"version": "1.0.0",
+		},
+		"servers": []gin.H{{"url": "/"}},
+		"paths":   paths,
+		"components": gin.H{
+			"securitySchemes": gin.H{
+				authUser: gin.H{"type": "http", "scheme": "bearer", "description": "登录接口返回的用户访问令牌"},
+				authAdmin: gin.H{"type": "http", "scheme": "bearer",
+					"description": "服务器配置中的 admin.token；来自 admin.allowed_ips 的直连请求可以不带令牌"},
+			},
+			"schemas": schemas.schemas,
+		},
+	}
+}
+
+func buildOperation(doc routeDoc, pathParams []string, schemas *schemaRegistry) gin.H {
+	success := gin.H{"description": "成功"}
+	switch {
+	case len(doc.Produces) > 0:
+		content := gin.H{}
+		for _, ct := range doc.Produces {
+			content[ct] = gin.H{}
+		}
+		success["content"] = content
+	case doc.Response != nil:
+		success["content"] = gin.H{"application/json": gin.H{"schema": schemas.schemaOf(reflect.TypeOf(doc.Response))}}
+	default:
+		success["content"] = gin.H{"application/json": gin.H{}}
+	}
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	op := gin.H{
+		"tags":    []string{doc.Tag},
+		"summary": doc.Summary,
+		"responses": gin.H{
+			strconv.Itoa(status): success,
+			"default": gin.H{
+				"description": "错误",
+				"content": gin.H{"application/json": gin.H{
+					"schema": gin.H{"$ref": "#/components/schemas/Error"},
+				}},
+			},
+		},
+	}
+
+	var params []gin.H
+	for _, name := range pathParams {
+		params = append(params, gin.H{"name": name, "in": "path", "required": true, "schema": gin.H{"type": "string"}})
+	}
+	for _, name := range doc.Query {
+		params = append(params, gin.H{"name": name, "in": "query", "schema": gin.H{"type": "string"}})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if doc.Request != nil {
+		op["requestBody"] = gin.H{
+			"required": true,
+			"content":  gin.H{"application/json": gin.H{"schema": schemas.schemaOf(reflect.TypeOf(doc.Request))}},
+		}
+	}
+	if doc.Auth != "" {
+		op["security"] = []gin.H{{doc.Auth: []string{}}}
+	}
+	return op
+}
+
+// schemaRegistry 按 Go 类型生成 JSON Schema：导出的具名结构体放入 components.schemas 并以 $ref 引用，
+// 其它类型就地展开
+type schemaRegistry struct {
+	schemas gin.H
+	// names 已登记的类型在 components.schemas 中的名称
+	names map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: gin.H{}, names: map[reflect.Type]string{}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (r *schemaRegistry) schemaOf(t reflect.Type) gin.H {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return gin.H{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return gin.H{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return gin.H{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gin.H{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return gin.H{"type": "number"}
+	case reflect.String:
+		return gin.H{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return gin.H{"type": "string", "format": "byte"}
+		}
+		return gin.H{"type": "array", "items": r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return gin.H{"type": "object", "additionalProperties": r.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if !token.IsExported(t.Name()) {
+			return r.objectSchema(t)
+		}
+		name, ok := r.names[t]
+		if !ok {
+			name = r.componentName(t)
+			r.names[t] = name
+			r.schemas[name] = gin.H{} // 先占位，自引用的类型不会无限递归
+			r.schemas[name] = r.objectSchema(t)
+		}
+		return gin.H{"$ref": "#/components/schemas/" + name}
+	default:
+		return gin.H{}
+	}
+}
+
+// componentName 优先使用类型名；不同包中的同名类型
+// 以包路径限定，避免后登记的类型覆盖先登记的
+func (r *schemaRegistry) componentName(t reflect.Type) string {
+	if _, taken := r.schemas[t.Name()]; !taken {
+		return t.Name()
+	}
+	return strings.ReplaceAll(t.PkgPath(), "/", ".") + "." + t.Name()
+}
+
+// objectSchema 按 encoding/json 的规则取字段名，binding:"required" 的字段列为必填
+func (r *schemaRegistry) objectSchema(t reflect.Type) gin.H {
+	props := gin.H{}
+	var required []string
+	for _, f := range jsonFields(t) {
+		props[f.name] = r.schemaOf(f.typ)
+		if f.required {
+			required = append(required, f.name)
+		}
+	}
+	schema := gin.H{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+type jsonField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+	depth    int
+	tagged   bool
+}
+
+// jsonFields 结构体序列化后的字段，与 encoding/json 一致：没有 json 名称的匿名结构体字段展开到外层；
+// 同名字段取嵌入层级最浅的，同一层级中只有一个带 json 名称时取它，否则都不输出
+func jsonFields(t reflect.Type) []jsonField {
+	var all []jsonField
+	collectJSONFields(t, 0, map[reflect.Type]bool{}, &all)
+
+	byName := map[string][]jsonField{}
+	var order []string
+	for _, f := range all {
+		if _, ok := byName[f.name]; !ok {
+			order = append(order, f.name)
+		}
+		byName[f.name] = append(byName[f.name], f)
+	}
+	var fields []jsonField
+	for _, name := range order {
+		if f, ok := dominantField(byName[name]); ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func collectJSONFields(t reflect.Type, depth int, visiting map[reflect.Type]bool, out *[]jsonField) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		ft := f.Type
+		if f.Anonymous && ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		// 未导出的匿名结构体字段仍会展开其导出字段
+		if f.Anonymous {
+			if !f.IsExported() && ft.Kind() != reflect.Struct {
+				continue
+			}
+		} else if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && f.Anonymous && ft.Kind() == reflect.Struct {
+			collectJSONFields(ft, depth+1, visiting, out)
+			continue
+		}
+		tagged := name != ""
+		if !tagged {
+			name = f.Name
+		}
+		*out = append(*out, jsonField{
+			name:     name,
+			typ:      f.Type,
+			required: strings.Contains(f.Tag.Get("binding"), "required"),
+			depth:    depth,
+			tagged:   tagged,
+		})
+	}
+}
+
+// dominantField 同名字段中实际被 encoding/json 输出的那个
+func dominantField(fields []jsonField) (jsonField, bool) {
+	minDepth := fields[0].depth
+	for _, f := range fields[1:] {
+		if f.depth < minDepth {
+			minDepth = f.depth
+		}
+	}
+	var shallow, tagged []jsonField
+	for _, f := range fields {
+		if f.depth != minDepth {
+			continue
+		}
+		shallow = append(shallow, f)
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+	switch {
+	case len(shallow) == 1:
+		return shallow[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	default:
+		return jsonField{}, false
+	}
+}
+
+// toOpenAPIPath 把 gin 的 :param / *param 转成 OpenAPI 的 {param}
+func toOpenAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			name := seg[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+  <meta charset="utf-8">
+  <title>Pic API 文档</title>
+  <link rel="stylesheet" href="/api/docs/assets/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/api/docs/assets/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: '/api/docs/openapi.json', dom_id: '#swagger-ui' });
+  </script>
+</body>
+</html>
+`
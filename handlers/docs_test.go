@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pic/jobs"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	noop := func(*gin.Context) {}
+	r.GET("/api/announcements/current", noop)
+	r.PUT("/api/admin/settings", noop)
+	r.POST("/api/admin/announcements", noop)
+	r.GET("/api/admin/jobs", noop)
+	r.GET("/api/qrcode", noop)
+	r.GET("/api/undocumented", noop)
+
+	raw, err := json.Marshal(buildOpenAPISpec(r.Routes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Security    []map[string][]string `json:"security"`
+			RequestBody struct {
+				Content map[string]struct {
+					Schema map[string]interface{} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Responses map[string]struct {
+				Content map[string]struct {
+					Schema map[string]interface{} `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+		Components struct {
+			SecuritySchemes map[string]interface{}            `json:"securitySchemes"`
+			Schemas         map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		path, method, auth string
+	}{
+		{"/api/announcements/current", "get", authUser},
+		{"/api/admin/settings", "put", authAdmin},
+		{"/api/admin/announcements", "post", authAdmin},
+		{"/api/admin/jobs", "get", authAdmin},
+		{"/api/undocumented", "get", ""},
+	} {
+		op := spec.Paths[tc.path][tc.method]
+		var got string
+		if len(op.Security) == 1 {
+			for name := range op.Security[0] {
+				got = name
+			}
+		}
+		if got != tc.auth {
+			t.Errorf("%s %s 的认证方式为 %q，期望 %q", tc.method, tc.path, got, tc.auth)
+		}
+		if _, ok := spec.Components.SecuritySchemes[tc.auth]; tc.auth != "" && !ok {
+			t.Errorf("securitySchemes 缺少 %s", tc.auth)
+		}
+	}
+
+	settingsBody := spec.Paths["/api/admin/settings"]["put"].RequestBody.Content["application/json"].Schema
+	if settingsBody["$ref"] != "#/components/schemas/Settings" {
+		t.Fatalf("PUT /api/admin/settings 请求体 schema = %v", settingsBody)
+	}
+	props, _ := spec.Components.Schemas["Settings"]["properties"].(map[string]interface{})
+	if _, ok := props["allowed_file_types"]; !ok {
+		t.Fatalf("Settings schema 缺少字段: %v", spec.Components.Schemas["Settings"])
+	}
+
+	created, ok := spec.Paths["/api/admin/announcements"]["post"].Responses["201"]
+	if !ok || created.Content["application/json"].Schema["$ref"] != "#/components/schemas/Announcement" {
+		t.Fatalf("发布公告的 201 响应 = %+v", spec.Paths["/api/admin/announcements"]["post"].Responses)
+	}
+	if req := spec.Paths["/api/admin/announcements"]["post"].RequestBody.Content["application/json"].Schema; req["$ref"] != "#/components/schemas/AnnouncementRequest" {
+		t.Fatalf("发布公告的请求体 schema = %v", req)
+	}
+	if spec.Components.Schemas["AnnouncementRequest"]["required"] == nil {
+		t.Fatalf("公告请求体未标记必填字段: %v", spec.Components.Schemas["AnnouncementRequest"])
+	}
+	for _, name := range []string{"Announcement", "AnnouncementRequest", "Job", "Error"} {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("components.schemas 缺少 %s", name)
+		}
+	}
+	if _, ok := spec.Paths["/api/qrcode"]["get"].Responses["200"].Content["image/png"]; !ok {
+		t.Errorf("二维码接口缺少 image/png 响应")
+	}
+}
+
+func TestSwaggerUIAssets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/docs", SwaggerUI)
+	r.GET("/api/docs/assets/:file", SwaggerAsset)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	if strings.Contains(w.Body.String(), "://") {
+		t.Fatalf("文档页引用了外部资源:\n%s", w.Body)
+	}
+
+	for _, tc := range []struct {
+		file        string
+		code        int
+		contentType string
+	}{
+		{"swagger-ui.css", http.StatusOK, "text/css"},
+		{"swagger-ui-bundle.js", http.StatusOK, "application/javascript"},
+		{"index.html", http.StatusNotFound, "application/json"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs/assets/"+tc.file, nil))
+		if w.Code != tc.code || !strings.HasPrefix(w.Header().Get("Content-Type"), tc.contentType) {
+			t.Errorf("GET %s = %d %s", tc.file, w.Code, w.Header().Get("Content-Type"))
+		}
+		if tc.code == http.StatusOK && w.Body.Len() == 0 {
+			t.Errorf("%s 内容为空", tc.file)
+		}
+	}
+}
+
+// Job 与 jobs.Job 同名，用于检查 components.schemas 的名称冲突
+type Job struct {
+	Name string `json:"name"`
+}
+
+type docsBase struct {
+	ID      uint   `json:"id"`
+	Comment string `json:"comment"`
+}
+
+type DocsAudit struct {
+	Comment string `json:"comment"`
+	By      string `json:"by"`
+}
+
+type docsEmbedded struct {
+	docsBase
+	*DocsAudit
+	Named   DocsAudit `json:"named"`
+	Comment string    `json:"comment"`
+	By      int       `json:"-"`
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	r := newSchemaRegistry()
+
+	// 匿名字段与 encoding/json 一样展开，外层同名字段优先
+	props := r.schemaOf(reflect.TypeOf(docsEmbedded{}))["properties"].(gin.H)
+	var names []string
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "by,comment,id,named" {
+		t.Fatalf("字段 = %s", got)
+	}
+	if props["comment"].(gin.H)["type"] != "string" || props["by"].(gin.H)["type"] != "string" {
+		t.Fatalf("字段 schema = %v", props)
+	}
+
+	// 不同包中的同名类型不能互相覆盖
+	local := r.schemaOf(reflect.TypeOf(Job{}))["$ref"]
+	queued := r.schemaOf(reflect.TypeOf(jobs.Job{}))["$ref"]
+	if local != "#/components/schemas/Job" || queued != "#/components/schemas/pic.jobs.Job" {
+		t.Fatalf("$ref = %v, %v", local, queued)
+	}
+	if again := r.schemaOf(reflect.TypeOf(&jobs.Job{}))["$ref"]; again != queued {
+		t.Fatalf("同一类型的 $ref 不一致: %v", again)
+	}
+	if _, ok := r.schemas["pic.jobs.Job"].(gin.H)["properties"].(gin.H)["status"]; !ok {
+		t.Fatalf("jobs.Job schema = %v", r.schemas["pic.jobs.Job"])
+	}
+}
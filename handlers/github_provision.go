@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"pic/i18n"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 图床仓库默认的 .gitignore，只排除系统生成的垃圾文件
+const defaultRepoGitignore = `.DS_Store
+Thumbs.db
+desktop.ini
+`
+
+var repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+// gitHubClient 访问 GitHub 的 HTTP 客户端，Transport 由 SetGitHubTransport 设置（代理、镜像改写和指标）
+var gitHubClient = &http.Client{Timeout: 30 * time.Second}
+
+// SetGitHubTransport 设置访问 GitHub 使用的 Transport（见 config.NewGitHubTransport），需在启动服务前调用
+func SetGitHubTransport(rt http.RoundTripper) {
+	gitHubClient.Transport = rt
+}
+
+// gitHubAPI GitHub REST API 地址，镜像地址由 gitHubClient 的 Transport 改写
+var gitHubAPI = "https://api.github.com"
+
+// CreateRepositoryRequest 创建图床仓库请求
+type CreateRepositoryRequest struct {
+	Token       string `json:"token" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Private     bool   `json:"private"`
+	Branch      string `json:"branch"`
+	EnablePages bool   `json:"enable_pages"`
+}
+
+type provisionedRepo struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Private       bool   `json:"private"`
+	HTMLURL       string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+	Owner         struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+// CreateRepository 代用户创建一个新的 GitHub 仓库用作图床
+func CreateRepository(c *gin.Context) {
+	var req CreateRepositoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.InvalidRequest))
+		return
+	}
+	if !repoNamePattern.MatchString(req.Name) {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.InvalidRepoName))
+		return
+	}
+	if req.Branch == "" {
+		req.Branch = "main"
+	}
+
+	var repo provisionedRepo
+	ctx := c.Request.Context()
+	status, err := callGitHubAPI(ctx, req.Token, http.MethodPost, "/user/repos", gin.H{
+		"name":        req.Name,
+		"description": req.Description,
+		"private":     req.Private,
+		"auto_init":   true,
+		"has_issues":  false,
+		"has_wiki":    false,
+	}, &repo)
+	if err != nil {
+		var apiErr *gitHubAPIError
+		if errors.As(err, &apiErr) && apiErr.rateLimited() {
+			if retry := apiErr.header.Get("Retry-After"); retry != "" {
+				c.Header("Retry-After", retry)
+			}
+			c.JSON(http.StatusTooManyRequests, i18n.Error(c, i18n.GitHubRateLimited))
+			return
+		}
+		switch status {
+		case http.StatusUnauthorized:
+			c.JSON(http.StatusUnauthorized, i18n.Error(c, i18n.GitHubTokenInvalid))
+		case http.StatusForbidden:
+			// 没有被限流的 403 是令牌缺少 repo 权限（或 fine-grained 令牌未授权创建仓库）
+			c.JSON(http.StatusForbidden, i18n.Error(c, i18n.GitHubTokenScope))
+		case http.StatusUnprocessableEntity:
+			// 422 也用于描述过长、组织策略禁止等校验失败，只有重名才是冲突
+			if apiErr != nil && apiErr.nameTaken() {
+				c.JSON(http.StatusConflict, i18n.Error(c, i18n.RepoUnavailable))
+				return
+			}
+			c.JSON(http.StatusUnprocessableEntity, i18n.Error(c, i18n.RepoCreateFailed, err))
+		default:
+			c.JSON(http.StatusBadGateway, i18n.Error(c, i18n.RepoCreateFailed, err))
+		}
+		return
+	}
+
+	// 以下步骤失败不影响仓库本身，收集为警告返回给前端
+	var warnings []string
+	repoPath := "/repos/" + url.PathEscape(repo.Owner.Login) + "/" + url.PathEscape(repo.Name)
+
+	if req.Branch != repo.DefaultBranch {
+		_, err := callGitHubAPI(ctx, req.Token, http.MethodPost,
+			repoPath+"/branches/"+url.PathEscape(repo.DefaultBranch)+"/rename",
+			gin.H{"new_name": req.Branch}, nil)
+		if err != nil {
+			warnings = append(warnings, i18n.Text(c, i18n.RepoRenameFailed, err))
+		} else {
+			repo.DefaultBranch = req.Branch
+		}
+	}
+
+	_, err = callGitHubAPI(ctx, req.Token, http.MethodPut, repoPath+"/contents/.gitignore", gin.H{
+		"message": "Add .gitignore",
+		"content": base64.StdEncoding.EncodeToString([]byte(defaultRepoGitignore)),
+		"branch":  repo.DefaultBranch,
+	}, nil)
+	if err != nil {
+		warnings = append(warnings, i18n.Text(c, i18n.RepoGitignoreFailed, err))
+	}
+
+	pagesURL := ""
+	if req.EnablePages {
+		var pages struct {
+			HTMLURL string `json:"html_url"`
+		}
+		_, err := callGitHubAPI(ctx, req.Token, http.MethodPost, repoPath+"/pages", gin.H{
+			"source": gin.H{"branch": repo.DefaultBranch, "path": "/"},
+		}, &pages)
+		if err != nil {
+			// 免费账户的私有仓库无法开启 Pages
+			warnings = append(warnings, i18n.Text(c, i18n.RepoPagesFailed, err))
+		} else {
+			pagesURL = pages.HTMLURL
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   i18n.Text(c, i18n.RepoCreated),
+		"owner":     repo.Owner.Login,
+		"name":      repo.Name,
+		"full_name": repo.FullName,
+		"private":   repo.Private,
+		"branch":    repo.DefaultBranch,
+		"html_url":  repo.HTMLURL,
+		"pages_url": pagesURL,
+		"warnings":  warnings,
+	})
+}
+
+// gitHubAPIError GitHub 非 2xx 响应的错误体，Errors 为 422 校验失败的明细
+type gitHubAPIError struct {
+	status int
+	header http.Header
+
+	Message string `json:"message"`
+	Errors  []struct {
+		Field   string `json:"field"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func (e *gitHubAPIError) Error() string {
+	details := make([]string, 0, len(e.Errors))
+	for _, item := range e.Errors {
+		if item.Message != "" {
+			details = append(details, item.Message)
+		} else if item.Field != "" {
+			details = append(details, item.Field+" "+item.Code)
+		}
+	}
+	if len(details) == 0 {
+		return e.Message
+	}
+	return e.Message + " (" + strings.Join(details, "; ") + ")"
+}
+
+// rateLimited 判断是否被限流：429，或带 Retry-After（次级限流）/ 额度为 0（主限流）的 403
+func (e *gitHubAPIError) rateLimited() bool {
+	switch e.status {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return e.header.Get("Retry-After") != "" || e.header.Get("X-RateLimit-Remaining") == "0"
+	}
+	return false
+}
+
+// nameTaken 判断是否因同名仓库已存在而创建失败
+func (e *gitHubAPIError) nameTaken() bool {
+	for _, item := range e.Errors {
+		if strings.Contains(strings.ToLower(item.Message), "name already exists") {
+			return true
+		}
+	}
+	return false
+}
+
+// callGitHubAPI 调用 GitHub REST API，返回 HTTP 状态码；非 2xx 时返回 *gitHubAPIError
+// ctx 取自客户端请求，客户端断开或请求超时后不再等待 GitHub，链路追踪也能关联到同一请求
+func callGitHubAPI(ctx context.Context, token, method, path string, body interface{}, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, gitHubAPI+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := gitHubClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &gitHubAPIError{status: resp.StatusCode, header: resp.Header}
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return resp.StatusCode, apiErr
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
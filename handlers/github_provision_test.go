@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateRepositoryErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		header map[string]string
+		body   string
+		want   int
+		code   string
+		detail string
+	}{
+		{"bad token", http.StatusUnauthorized, nil, `{"message":"Bad credentials"}`, http.StatusUnauthorized, "github_token_invalid", ""},
+		{"missing scope", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "4999"},
+			`{"message":"Resource not accessible by personal access token"}`, http.StatusForbidden, "github_token_scope", ""},
+		{"primary rate limit", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"},
+			`{"message":"API rate limit exceeded"}`, http.StatusTooManyRequests, "github_rate_limited", ""},
+		{"secondary rate limit", http.StatusForbidden, map[string]string{"Retry-After": "60"},
+			`{"message":"You have exceeded a secondary rate limit"}`, http.StatusTooManyRequests, "github_rate_limited", ""},
+		{"too many requests", http.StatusTooManyRequests, map[string]string{"Retry-After": "30"}, ``, http.StatusTooManyRequests, "github_rate_limited", ""},
+		{"name taken", http.StatusUnprocessableEntity, nil,
+			`{"message":"Repository creation failed.","errors":[{"resource":"Repository","code":"custom","field":"name","message":"name already exists on this account"}]}`,
+			http.StatusConflict, "repo_unavailable", ""},
+		{"other validation", http.StatusUnprocessableEntity, nil,
+			`{"message":"Repository creation failed.","errors":[{"resource":"Repository","code":"custom","field":"visibility","message":"visibility can't be private. Please upgrade your plan"}]}`,
+			http.StatusUnprocessableEntity, "repo_create_failed", "visibility can't be private"},
+		{"validation without details", http.StatusUnprocessableEntity, nil, `{"message":"Validation Failed"}`,
+			http.StatusUnprocessableEntity, "repo_create_failed", "Validation Failed"},
+		{"github down", http.StatusBadGateway, nil, ``, http.StatusBadGateway, "repo_create_failed", "502 Bad Gateway"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/user/repos" {
+					t.Errorf("意外的请求 %s %s", r.Method, r.URL.Path)
+				}
+				for k, v := range tc.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+			defer func(old string) { gitHubAPI = old }(gitHubAPI)
+			gitHubAPI = srv.URL
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.POST("/repos", CreateRepository)
+			req := httptest.NewRequest(http.MethodPost, "/repos", strings.NewReader(`{"token":"t","name":"images"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", "en")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.want {
+				t.Fatalf("状态码 = %d, 期望 %d: %s", w.Code, tc.want, w.Body)
+			}
+			if !strings.Contains(w.Body.String(), `"code":"`+tc.code+`"`) || !strings.Contains(w.Body.String(), tc.detail) {
+				t.Fatalf("响应体应包含错误码 %s 和 %q: %s", tc.code, tc.detail, w.Body)
+			}
+			if retry := tc.header["Retry-After"]; retry != "" && w.Header().Get("Retry-After") != retry {
+				t.Fatalf("Retry-After = %q, 期望 %q", w.Header().Get("Retry-After"), retry)
+			}
+		})
+	}
+}
+
+// 客户端请求取消后不再调用 GitHub
+func TestCallGitHubAPIContext(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer srv.Close()
+	defer func(old string) { gitHubAPI = old }(gitHubAPI)
+	gitHubAPI = srv.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := callGitHubAPI(ctx, "t", http.MethodGet, "/user", nil, nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, 期望 context.Canceled", err)
+	}
+	if called {
+		t.Fatal("请求已取消仍然访问了 GitHub")
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"pic/config"
+	"pic/i18n"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
+)
+
+var startedAt = time.Now()
+
+// checkResult 单项就绪检查结果
+type checkResult struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	// Critical 为 false 的检查失败时只降级，不影响就绪状态
+	Critical bool `json:"critical"`
+	// err 检查失败的原因，由 Readyz 按请求语言写入 Error
+	err error
+}
+
+// GitHub 检查结果缓存，避免探针过于频繁地访问 GitHub；缓存过期时并发的探针共用同一次请求
+var (
+	gitHubCheckMu    sync.Mutex
+	gitHubCheckCache checkResult
+	gitHubCheckAt    time.Time
+	gitHubCheckGroup singleflight.Group
+)
+
+const gitHubCheckTTL = 30 * time.Second
+
+// Healthz 存活探针：进程能响应即视为存活
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         "ok",
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	})
+}
+
+// Readyz 就绪探针：检查数据库、Redis（如已配置）和 GitHub API 是否可用。
+// 配置了 github.health_repo 时用 github.token 访问该仓库，令牌失效或无权访问时不就绪。
+func Readyz(c *gin.Context) {
+	github := config.Current().GitHub
+	checks := map[string]checkResult{
+		"database": checkDatabase(c.Request.Context()),
+		"github":   checkGitHub(c.Request.Context(), github.HealthRepo, github.Token),
+	}
+	if config.Redis != nil {
+		checks["redis"] = checkRedis(c.Request.Context())
+	}
+	lang := i18n.Negotiate(c)
+	for name, check := range checks {
+		if check.err != nil {
+			check.Error = i18n.Localize(lang, check.err)
+			checks[name] = check
+		}
+	}
+
+	status, code := "ok", http.StatusOK
+	for _, check := range checks {
+		if check.Status == "ok" {
+			continue
+		}
+		if check.Critical {
+			status, code = "unavailable", http.StatusServiceUnavailable
+			break
+		}
+		status = "degraded"
+	}
+
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+func checkDatabase(ctx context.Context) checkResult {
+	result := checkResult{Status: "ok", Critical: true}
+	start := time.Now()
+
+	if config.DB == nil {
+		result.Status, result.err = "error", i18n.NewError(i18n.DatabaseNotReady)
+		return result
+	}
+	sqlDB, err := config.DB.DB()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		result.Status, result.err = "error", err
+	}
+	result.LatencyMS = time.Since(start).Milliseconds()
+	return result
+}
+
+func checkRedis(ctx context.Context) checkResult {
+	result := checkResult{Status: "ok", Critical: true}
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := config.Redis.Ping(ctx).Err(); err != nil {
+		result.Status, result.err = "error", err
+	}
+	result.LatencyMS = time.Since(start).Milliseconds()
+	return result
+}
+
+// checkGitHub repo 为空时访问 /rate_limit（不消耗请求额度）检查 API 是否可达；
+// 否则带 token 访问 /repos/{owner}/{repo}，401/404 和非限流的 403 说明令牌或权限配置错误，视为关键失败。
+// 限流、GitHub 不可达或返回 5xx 只降级：各副本共用同一令牌，限流时不能让所有副本同时不就绪。
+func checkGitHub(ctx context.Context, repo, token string) checkResult {
+	gitHubCheckMu.Lock()
+	cached, checkedAt := gitHubCheckCache, gitHubCheckAt
+	gitHubCheckMu.Unlock()
+	if time.Since(checkedAt) < gitHubCheckTTL {
+		return cached
+	}
+
+	// 不持锁等待 GitHub 响应；请求不随发起它的探针取消，其它探针还在等待结果
+	v, _, _ := gitHubCheckGroup.Do("github", func() (interface{}, error) {
+		result := probeGitHub(context.WithoutCancel(ctx), repo, token)
+		gitHubCheckMu.Lock()
+		gitHubCheckCache, gitHubCheckAt = result, time.Now()
+		gitHubCheckMu.Unlock()
+		return result, nil
+	})
+	return v.(checkResult)
+}
+
+func probeGitHub(ctx context.Context, repo, token string) checkResult {
+	result := checkResult{Status: "ok"}
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	path := "/rate_limit"
+	if repo != "" {
+		path = "/repos/" + repo
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gitHubAPI+path, nil)
+	if err == nil {
+		if repo != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set("Accept", "application/vnd.github+json")
+		}
+		var resp *http.Response
+		resp, err = gitHubClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			rateLimited := resp.StatusCode == http.StatusTooManyRequests ||
+				(resp.StatusCode == http.StatusForbidden &&
+					(resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"))
+			switch {
+			case rateLimited:
+				result.Status, result.err = "error", i18n.NewError(i18n.GitHubRateLimited)
+			case repo != "" && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusNotFound):
+				result.Status, result.Critical = "error", true
+				result.err = i18n.NewError(i18n.GitHubRepoDenied, repo, resp.Status)
+			case resp.StatusCode >= 500:
+				result.Status, result.err = "error", errors.New(resp.Status)
+			}
+		}
+	}
+	if err != nil {
+		result.Status, result.err = "error", err
+	}
+	result.LatencyMS = time.Since(start).Milliseconds()
+	return result
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckGitHub(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		repo     string
+		status   int
+		header   map[string]string
+		want     string
+		critical bool
+	}{
+		{"reachable", "", http.StatusOK, nil, "ok", false},
+		{"unauthenticated ignores 401", "", http.StatusUnauthorized, nil, "ok", false},
+		{"repo ok", "owner/repo", http.StatusOK, nil, "ok", false},
+		{"bad token", "owner/repo", http.StatusUnauthorized, nil, "error", true},
+		{"forbidden", "owner/repo", http.StatusForbidden, nil, "error", true},
+		{"repo missing", "owner/repo", http.StatusNotFound, nil, "error", true},
+		{"github down", "owner/repo", http.StatusBadGateway, nil, "error", false},
+		{"primary rate limit", "owner/repo", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}, "error", false},
+		{"secondary rate limit", "owner/repo", http.StatusForbidden, map[string]string{"Retry-After": "60"}, "error", false},
+		{"too many requests", "", http.StatusTooManyRequests, nil, "error", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotPath, gotAuth string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+				for k, v := range tc.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+			defer func(old string) { gitHubAPI = old }(gitHubAPI)
+			gitHubAPI = srv.URL
+			gitHubCheckAt = time.Time{}
+
+			result := checkGitHub(context.Background(), tc.repo, "secret")
+			if result.Status != tc.want || result.Critical != tc.critical {
+				t.Fatalf("结果 = %+v, 期望 status=%s critical=%v", result, tc.want, tc.critical)
+			}
+			if (result.err != nil) != (tc.want == "error") {
+				t.Fatalf("err = %v", result.err)
+			}
+			if tc.repo == "" {
+				if gotPath != "/rate_limit" || gotAuth != "" {
+					t.Fatalf("未配置仓库时应匿名访问 /rate_limit，实际 %s %q", gotPath, gotAuth)
+				}
+				return
+			}
+			if gotPath != "/repos/"+tc.repo || gotAuth != "Bearer secret" {
+				t.Fatalf("请求 = %s %q", gotPath, gotAuth)
+			}
+		})
+	}
+}
+
+// 结果在 gitHubCheckTTL 内复用，探针不会每次都访问 GitHub
+func TestCheckGitHubCached(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	defer func(old string) { gitHubAPI = old }(gitHubAPI)
+	gitHubAPI = srv.URL
+	gitHubCheckAt = time.Time{}
+
+	for i := 0; i < 3; i++ {
+		if result := checkGitHub(context.Background(), "owner/repo", "secret"); result.Status != "error" {
+			t.Fatalf("第 %d 次检查结果 = %+v", i, result)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("访问了 GitHub %d 次，期望 1 次", calls)
+	}
+}
+
+// 缓存过期时并发的探针合并为一次 GitHub 请求
+func TestCheckGitHubConcurrent(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+	}))
+	defer srv.Close()
+	defer func(old string) { gitHubAPI = old }(gitHubAPI)
+	gitHubAPI = srv.URL
+	gitHubCheckAt = time.Time{}
+
+	var wg sync.WaitGroup
+	results := make([]checkResult, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = checkGitHub(context.Background(), "owner/repo", "secret")
+		}(i)
+	}
+	// 等请求到达 GitHub 后再放行，其余探针此时应在等待同一次请求
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("访问了 GitHub %d 次，期望 1 次", n)
+	}
+	for i, result := range results {
+		if result.Status != "ok" {
+			t.Fatalf("第 %d 个探针结果 = %+v", i, result)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pic/announcement"
+	"pic/i18n"
+	"pic/jobs"
+	"pic/settings"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+var cjk = regexp.MustCompile(`\p{Han}`)
+
+// Accept-Language: en 时错误和提示文本（包括校验错误的细节）都不能混入中文
+func TestEnglishResponses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	db := newTestDB(t)
+	if err := settings.Init(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := announcement.Init(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := jobs.Init(db); err != nil {
+		t.Fatal(err)
+	}
+	a := announcement.Announcement{Title: "maintenance"}
+	if err := announcement.Create(&a); err != nil {
+		t.Fatal(err)
+	}
+	job, err := jobs.Enqueue(ctx, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.PUT("/settings", UpdateSystemSettings)
+	r.POST("/announcements", CreateAnnouncement)
+	r.DELETE("/announcements/:id", DeleteAnnouncement)
+	r.DELETE("/jobs/:id", DeleteJob)
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		want   string
+	}{
+		{"settings validation", http.MethodPut, "/settings", `{"max_upload_size_mb": 0}`, http.StatusBadRequest, "Invalid request: "},
+		{"settings file type", http.MethodPut, "/settings", `{"allowed_file_types": ["bad.ext"]}`, http.StatusBadRequest, "bad.ext"},
+		{"settings saved", http.MethodPut, "/settings", `{}`, http.StatusOK, `"message":"System settings saved"`},
+		{"announcement validation", http.MethodPost, "/announcements", `{"title": ""}`, http.StatusBadRequest, "Invalid request: "},
+		{"announcement bad level", http.MethodPost, "/announcements", `{"title": "x", "level": "loud"}`, http.StatusBadRequest, "Invalid request: "},
+		{"announcement deleted", http.MethodDelete, "/announcements/" + itoa(a.ID), "", http.StatusOK, `"message":"Announcement deleted"`},
+		{"announcement not found", http.MethodDelete, "/announcements/" + itoa(a.ID), "", http.StatusNotFound, `"code":"` + string(i18n.AnnouncementNotFound) + `"`},
+		{"job deleted", http.MethodDelete, "/jobs/" + itoa(job.ID), "", http.StatusOK, `"message":"Job deleted"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", "en-US,en;q=0.9")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.status {
+				t.Fatalf("状态码 = %d, 期望 %d: %s", w.Code, tc.status, w.Body)
+			}
+			if got := w.Header().Get("Content-Language"); got != i18n.English {
+				t.Fatalf("Content-Language = %q", got)
+			}
+			if cjk.MatchString(w.Body.String()) {
+				t.Fatalf("英文响应中包含中文: %s", w.Body)
+			}
+			if !strings.Contains(w.Body.String(), tc.want) {
+				t.Fatalf("响应体应包含 %q: %s", tc.want, w.Body)
+			}
+		})
+	}
+}
+
+func itoa(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
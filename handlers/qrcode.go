@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"pic/i18n"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	qrMaxURLLength = 2048
+	qrDefaultSize  = 256
+	qrMinSize      = 64
+	qrMaxSize      = 1024
+)
+
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"low":     qrcode.Low,
+	"medium":  qrcode.Medium,
+	"high":    qrcode.High,
+	"highest": qrcode.Highest,
+}
+
+// GetQRCode 为图片、分享链接或图库地址生成二维码，format 为 png（默认）或 svg
+func GetQRCode(c *gin.Context) {
+	target := c.Query("url")
+	u, err := url.Parse(target)
+	if target == "" || len(target) > qrMaxURLLength || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.QRCodeInvalidURL, qrMaxURLLength))
+		return
+	}
+
+	size, err := strconv.Atoi(c.DefaultQuery("size", strconv.Itoa(qrDefaultSize)))
+	if err != nil || size < qrMinSize || size > qrMaxSize {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.QRCodeInvalidSize, qrMinSize, qrMaxSize))
+		return
+	}
+
+	level, ok := qrLevels[c.DefaultQuery("level", "medium")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.QRCodeInvalidLevel))
+		return
+	}
+
+	format := c.DefaultQuery("format", "png")
+	if format != "png" && format != "svg" {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.QRCodeInvalidFormat))
+		return
+	}
+
+	qr, err := qrcode.New(target, level)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, i18n.Error(c, i18n.QRCodeFailed))
+		return
+	}
+
+	// 相同参数生成的二维码不变
+	c.Header("Cache-Control", "private, max-age=86400")
+	if format == "svg" {
+		c.Data(http.StatusOK, "image/svg+xml", qrSVG(qr.Bitmap(), size))
+		return
+	}
+	png, err := qr.PNG(size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, i18n.Error(c, i18n.QRCodeFailed))
+		return
+	}
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// qrSVG 将二维码矩阵（已含静区）绘制为 SVG，每个深色模块为 1x1 的方块，由 viewBox 缩放到 size
+func qrSVG(bitmap [][]bool, size int) []byte {
+	n := len(bitmap)
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			// 同一行相邻的深色模块合并为一段
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="%s"/></svg>`, n, n, path.String())
+	return []byte(b.String())
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"pic/i18n"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/skip2/go-qrcode"
+)
+
+func getQRCode(query url.Values) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/qrcode", GetQRCode)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/qrcode?"+query.Encode(), nil))
+	return w
+}
+
+func TestGetQRCodeValidation(t *testing.T) {
+	const target = "https://example.com/i/abc.png"
+	for _, tc := range []struct {
+		name  string
+		query url.Values
+		code  i18n.Code
+	}{
+		{"missing url", url.Values{}, i18n.QRCodeInvalidURL},
+		{"relative url", url.Values{"url": {"/i/abc.png"}}, i18n.QRCodeInvalidURL},
+		{"non http scheme", url.Values{"url": {"javascript:alert(1)"}}, i18n.QRCodeInvalidURL},
+		{"url too long", url.Values{"url": {"https://example.com/" + strings.Repeat("a", qrMaxURLLength)}}, i18n.QRCodeInvalidURL},
+		{"size not a number", url.Values{"url": {target}, "size": {"big"}}, i18n.QRCodeInvalidSize},
+		{"size too small", url.Values{"url": {target}, "size": {strconv.Itoa(qrMinSize - 1)}}, i18n.QRCodeInvalidSize},
+		{"size too large", url.Values{"url": {target}, "size": {strconv.Itoa(qrMaxSize + 1)}}, i18n.QRCodeInvalidSize},
+		{"unknown level", url.Values{"url": {target}, "level": {"max"}}, i18n.QRCodeInvalidLevel},
+		{"unknown format", url.Values{"url": {target}, "format": {"gif"}}, i18n.QRCodeInvalidFormat},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := getQRCode(tc.query)
+			if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"code":"`+string(tc.code)+`"`) {
+				t.Fatalf("响应 = %d %s，期望 400 %s", w.Code, w.Body, tc.code)
+			}
+		})
+	}
+}
+
+func TestGetQRCode(t *testing.T) {
+	const target = "https://example.com/g/alice"
+	w := getQRCode(url.Values{"url": {target}, "size": {"300"}, "level": {"high"}})
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("响应 = %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	img, err := png.Decode(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 300 || b.Dy() != 300 {
+		t.Fatalf("PNG 尺寸 = %v，期望 300x300", b)
+	}
+
+	w = getQRCode(url.Values{"url": {target}, "size": {"128"}, "format": {"svg"}})
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("响应 = %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	checkSVG(t, w.Body.Bytes(), 128)
+}
+
+// checkSVG 检查 qrSVG 输出是格式正确的 XML，根元素为指定尺寸的 <svg>
+func checkSVG(t *testing.T, data []byte, size int) {
+	t.Helper()
+	var doc struct {
+		XMLName xml.Name
+		Width   string `xml:"width,attr"`
+		Height  string `xml:"height,attr"`
+		ViewBox string `xml:"viewBox,attr"`
+		Rect    struct {
+			Width string `xml:"width,attr"`
+		} `xml:"rect"`
+		Path struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+	}
+	// 逐个读完所有 token，确保整个文档格式正确
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if err != io.EOF {
+				t.Fatalf("SVG 不是格式正确的 XML: %v\n%s", err, data)
+			}
+			break
+		}
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	want := strconv.Itoa(size)
+	if doc.XMLName.Local != "svg" || doc.XMLName.Space != "http://www.w3.org/2000/svg" || doc.Width != want || doc.Height != want {
+		t.Fatalf("根元素 = %+v，期望 %s x %s 的 svg", doc.XMLName, want, want)
+	}
+	if doc.ViewBox != "0 0 "+doc.Rect.Width+" "+doc.Rect.Width || doc.Path.D == "" {
+		t.Fatalf("viewBox = %q，背景宽度 %q，path 为空 = %v", doc.ViewBox, doc.Rect.Width, doc.Path.D == "")
+	}
+}
+
+func TestQRSVG(t *testing.T) {
+	qr, err := qrcode.New("https://example.com", qrcode.Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bitmap := qr.Bitmap()
+	data := qrSVG(bitmap, 512)
+	checkSVG(t, data, 512)
+
+	// 每个深色模块恰好被一段路径覆盖：路径的总宽度等于深色模块数
+	dark := 0
+	for _, row := range bitmap {
+		for _, on := range row {
+			if on {
+				dark++
+			}
+		}
+	}
+	covered := 0
+	for _, seg := range strings.Split(string(data), "M")[1:] {
+		var x, y, w int
+		if _, err := fmt.Sscanf(seg, "%d %dh%d", &x, &y, &w); err != nil {
+			t.Fatalf("无法解析路径段 %q: %v", seg, err)
+		}
+		covered += w
+	}
+	if covered != dark {
+		t.Fatalf("路径覆盖 %d 个模块，期望 %d", covered, dark)
+	}
+}
@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+	"pic/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRateLimit 查询当前 API 密钥的剩余调用额度和当前用户的上传额度，本请求不消耗额度
+func GetRateLimit(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"limits": middleware.RateLimitStatus(c, map[string]func(*gin.Context) string{
+		"api":    middleware.CredentialOrIPKey,
+		"upload": middleware.UserOrIPKey,
+	})})
+}
@@ -0,0 +1,144 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Code 接口错误码，客户端应以错误码而不是错误文本判断错误类型
+type Code string
+
+// 支持的语言，未匹配 Accept-Language 时使用 Default
+const (
+	Chinese = "zh"
+	English = "en"
+	Default = Chinese
+)
+
+// Message 按语言返回错误码对应的文本，args 用于填充文本中的占位符，
+// 其中的 error 参数按同一语言翻译（见 Localize）。该语言缺少翻译时回退到默认语言。
+func Message(lang string, code Code, args ...interface{}) string {
+	texts, ok := messages[code]
+	if !ok {
+		return string(code)
+	}
+	text, ok := texts[lang]
+	if !ok {
+		text = texts[Default]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	localized := make([]interface{}, len(args))
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			arg = Localize(lang, err)
+		}
+		localized[i] = arg
+	}
+	return fmt.Sprintf(text, localized...)
+}
+
+// CodedError 带错误码的错误，校验等函数返回它，由处理器按请求的语言翻译。
+// Error() 返回默认语言的文本，用于日志。
+type CodedError struct {
+	Code Code
+	Args []interface{}
+}
+
+// NewError 创建带错误码的错误，args 填充文本中的占位符
+func NewError(code Code, args ...interface{}) error {
+	return &CodedError{Code: code, Args: args}
+}
+
+func (e *CodedError) Error() string {
+	return Message(Default, e.Code, e.Args...)
+}
+
+// Unwrap 返回参数中的第一个 error，使 errors.Is/As 能找到被包装的原始错误
+func (e *CodedError) Unwrap() error {
+	for _, arg := range e.Args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// Localize 按语言返回错误文本：CodedError 翻译为对应文本，errors.Join 合并的错误逐个翻译，
+// 其它错误原样返回 Error()
+func Localize(lang string, err error) string {
+	if coded, ok := err.(*CodedError); ok {
+		return Message(lang, coded.Code, coded.Args...)
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		parts := make([]string, 0, len(joined.Unwrap()))
+		for _, e := range joined.Unwrap() {
+			parts = append(parts, Localize(lang, e))
+		}
+		return strings.Join(parts, "; ")
+	}
+	return err.Error()
+}
+
+// Error 按请求的 Accept-Language 生成错误响应体 {"error": 文本, "code": 错误码}，
+// 调用方可以在返回的 gin.H 上补充其它字段
+func Error(c *gin.Context, code Code, args ...interface{}) gin.H {
+	return gin.H{"error": Message(Negotiate(c), code, args...), "code": code}
+}
+
+// Text 按请求的 Accept-Language 返回提示文本，用于成功响应的 message 等字段
+func Text(c *gin.Context, code Code, args ...interface{}) string {
+	return Message(Negotiate(c), code, args...)
+}
+
+// Negotiate 按 Accept-Language 选出响应语言并设置 Content-Language 和 Vary，
+// 同一请求多次调用只添加一次 Vary
+func Negotiate(c *gin.Context) string {
+	lang := Language(c.GetHeader("Accept-Language"))
+	h := c.Writer.Header()
+	h.Set("Content-Language", lang)
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), "Accept-Language") {
+				return lang
+			}
+		}
+	}
+	h.Add("Vary", "Accept-Language")
+	return lang
+}
+
+// Language 从 Accept-Language 中选出权重最高的受支持语言，如 "en-US,en;q=0.9" 返回 en
+func Language(header string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if q > 0 && (primary == Chinese || primary == English) {
+			candidates = append(candidates, candidate{primary, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+	// 权重相同时保持请求头中的顺序
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
@@ -0,0 +1,115 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLanguage(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   string
+	}{
+		{"", Default},
+		{"en", English},
+		{"en-US,en;q=0.9", English},
+		{"zh-CN,zh;q=0.9,en;q=0.8", Chinese},
+		{"fr-FR,en;q=0.5,zh;q=0.8", Chinese},
+		{"EN-gb", English},
+		{"zh;q=0.5, en", English},
+		{"en;q=0, zh", Chinese},
+		{"en;q=abc, zh;q=0.1", Chinese},
+		{"fr, de", Default},
+		{"zh;q=0.8, en;q=0.8", Chinese},
+	} {
+		if got := Language(tc.header); got != tc.want {
+			t.Errorf("Language(%q) = %q, 期望 %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	coded := NewError(SettingsBadFileType, "a.b")
+	for _, tc := range []struct {
+		name string
+		lang string
+		code Code
+		args []interface{}
+		want string
+	}{
+		{"plain", English, Forbidden, nil, "Access denied"},
+		{"args", English, QRCodeInvalidSize, []interface{}{64, 1024}, "size must be between 64 and 1024"},
+		{"unknown language falls back", "fr", Forbidden, nil, "无权访问"},
+		{"unknown code", English, Code("nope"), nil, "nope"},
+		{"coded error argument translated", English, ValidationFailed, []interface{}{coded}, "Invalid request: Invalid file type: a.b"},
+		{"coded error argument in chinese", Chinese, ValidationFailed, []interface{}{coded}, "文件类型格式错误: a.b"},
+		{"plain error argument kept", English, MailSendFailed, []interface{}{errors.New("dial tcp: timeout")}, "Failed to send email: dial tcp: timeout"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Message(tc.lang, tc.code, tc.args...); got != tc.want {
+				t.Fatalf("Message = %q, 期望 %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCodedError(t *testing.T) {
+	cause := errors.New("cause")
+	err := NewError(MailSMTPError, cause)
+	if !errors.Is(err, cause) {
+		t.Fatal("CodedError 没有包装原始错误")
+	}
+	if got := err.Error(); got != "SMTP 服务器错误: cause" {
+		t.Fatalf("Error() = %q，应为默认语言", got)
+	}
+
+	joined := errors.Join(NewError(ConfigNegative, "a"), NewError(ConfigField, "b", NewError(RateLimitBadUnit, "w")), cause)
+	if got, want := Localize(English, joined), `a must not be negative; b: Unknown rate limit unit: "w"; cause`; got != want {
+		t.Fatalf("Localize = %q, 期望 %q", got, want)
+	}
+	// 被 fmt.Errorf 包装后不再翻译，调用方应直接返回 CodedError
+	if got := Localize(English, fmt.Errorf("wrapped: %w", NewError(ConfigNegative, "a"))); got != "wrapped: a 不能为负数" {
+		t.Fatalf("Localize = %q", got)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/", nil)
+	c.Request.Header.Set("Accept-Language", "en-US")
+
+	body := Error(c, Forbidden)
+	if body["error"] != "Access denied" || body["code"] != Forbidden {
+		t.Fatalf("Error = %v", body)
+	}
+	if got := Text(c, JobDeleted); got != "Job deleted" {
+		t.Fatalf("Text = %q", got)
+	}
+	if got := w.Header().Get("Content-Language"); got != English {
+		t.Fatalf("Content-Language = %q", got)
+	}
+	if got := w.Header().Values("Vary"); len(got) != 1 || got[0] != "Accept-Language" {
+		t.Fatalf("Vary = %v，同一请求只应添加一次", got)
+	}
+}
+
+var cjk = regexp.MustCompile(`\p{Han}`)
+
+// 每个错误码都要有中英文文本，英文文本中不能混入中文
+func TestCatalogueComplete(t *testing.T) {
+	for code, texts := range messages {
+		if texts[Chinese] == "" || texts[English] == "" {
+			t.Errorf("%s 缺少翻译: %v", code, texts)
+		}
+		if cjk.MatchString(texts[English]) {
+			t.Errorf("%s 的英文文本包含中文: %q", code, texts[English])
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package i18n
+
+// 错误码，新增错误时在 messages 中同时补充中英文文本
+const (
+	InvalidRequest   Code = "invalid_request"
+	InvalidParameter Code = "invalid_parameter"
+	ValidationFailed Code = "validation_failed"
+	InternalError    Code = "internal_error"
+	Forbidden        Code = "forbidden"
+	RateLimited      Code = "rate_limited"
+	BodyTooLarge     Code = "body_too_large"
+	APINotFound      Code = "api_not_found"
+	FrontendNotFound Code = "frontend_not_found"
+
+	Maintenance         Code = "maintenance"
+	ReadOnly            Code = "read_only"
+	RegistrationClosed  Code = "registration_closed"
+	GalleryDisabled     Code = "gallery_disabled"
+	InsufficientStorage Code = "insufficient_storage"
+
+	InvalidUploadForm   Code = "invalid_upload_form"
+	UnsupportedFileType Code = "unsupported_file_type"
+	FileTooLarge        Code = "file_too_large"
+
+	QRCodeInvalidURL    Code = "qrcode_invalid_url"
+	QRCodeInvalidSize   Code = "qrcode_invalid_size"
+	QRCodeInvalidLevel  Code = "qrcode_invalid_level"
+	QRCodeInvalidFormat Code = "qrcode_invalid_format"
+	QRCodeFailed        Code = "qrcode_failed"
+
+	InvalidRepoName     Code = "invalid_repo_name"
+	GitHubTokenInvalid  Code = "github_token_invalid"
+	GitHubTokenScope    Code = "github_token_scope"
+	GitHubRateLimited   Code = "github_rate_limited"
+	RepoUnavailable     Code = "repo_unavailable"
+	RepoCreateFailed    Code = "repo_create_failed"
+	RepoCreated         Code = "repo_created"
+	RepoRenameFailed    Code = "repo_rename_branch_failed"
+	RepoGitignoreFailed Code = "repo_gitignore_failed"
+	RepoPagesFailed     Code = "repo_pages_failed"
+
+	ConfigInvalid        Code = "config_invalid"
+	ConfigReloaded       Code = "config_reloaded"
+	SaveSettingsFailed   Code = "save_settings_failed"
+	SettingsSaved        Code = "settings_saved"
+	DiskMonitorDisabled  Code = "disk_monitor_disabled"
+	MaintenanceNotRun    Code = "maintenance_not_run"
+	MailRecipientMissing Code = "mail_recipient_missing"
+	SMTPDisabled         Code = "smtp_disabled"
+	MailSendFailed       Code = "mail_send_failed"
+	MailTestSent         Code = "mail_test_sent"
+	DatabaseNotReady     Code = "database_not_ready"
+	GitHubRepoDenied     Code = "github_repo_denied"
+
+	JobListFailed   Code = "job_list_failed"
+	JobCountFailed  Code = "job_count_failed"
+	JobIDInvalid    Code = "job_id_invalid"
+	JobNotFound     Code = "job_not_found"
+	JobGetFailed    Code = "job_get_failed"
+	JobNotRetryable Code = "job_not_retryable"
+	JobRetryFailed  Code = "job_retry_failed"
+	JobRunning      Code = "job_running"
+	JobDeleteFailed Code = "job_delete_failed"
+	JobRequeued     Code = "job_requeued"
+	JobDeleted      Code = "job_deleted"
+
+	AnnouncementGetFailed      Code = "announcement_get_failed"
+	AnnouncementCreateFailed   Code = "announcement_create_failed"
+	AnnouncementSaveFailed     Code = "announcement_save_failed"
+	AnnouncementDeleteFailed   Code = "announcement_delete_failed"
+	AnnouncementIDInvalid      Code = "announcement_id_invalid"
+	AnnouncementNotFound       Code = "announcement_not_found"
+	AnnouncementDeleted        Code = "announcement_deleted"
+	AnnouncementNotDismissible Code = "announcement_not_dismissible"
+	AnnouncementDismissFailed  Code = "announcement_dismiss_failed"
+	AnnouncementDismissed      Code = "announcement_dismissed"
+)
+
+// 校验错误码，由各模块通过 NewError 返回，作为 ValidationFailed、ConfigInvalid、MailSendFailed 的参数
+const (
+	SettingsBadUploadSize Code = "settings_bad_upload_size"
+	SettingsNoFileTypes   Code = "settings_no_file_types"
+	SettingsBadFileType   Code = "settings_bad_file_type"
+
+	AnnouncementNoTitle     Code = "announcement_no_title"
+	AnnouncementBadLevel    Code = "announcement_bad_level"
+	AnnouncementBadAudience Code = "announcement_bad_audience"
+	AnnouncementBadPeriod   Code = "announcement_bad_period"
+
+	ConfigReadFailed        Code = "config_read_failed"
+	ConfigParseFailed       Code = "config_parse_failed"
+	ConfigBadDuration       Code = "config_bad_duration"
+	ConfigBadEnv            Code = "config_bad_env"
+	ConfigBadFlag           Code = "config_bad_flag"
+	ConfigField             Code = "config_field"
+	ConfigBadValue          Code = "config_bad_value"
+	ConfigBadListen         Code = "config_bad_listen"
+	ConfigNoSocketPath      Code = "config_no_socket_path"
+	ConfigBadAddr           Code = "config_bad_addr"
+	ConfigNotOneOf          Code = "config_not_one_of"
+	ConfigNegative          Code = "config_negative"
+	ConfigNotPositive       Code = "config_not_positive"
+	ConfigTLSPair           Code = "config_tls_pair"
+	ConfigTLSExclusive      Code = "config_tls_exclusive"
+	ConfigTLSRedirect       Code = "config_tls_redirect"
+	ConfigCompression       Code = "config_compression"
+	ConfigGitHubToken       Code = "config_github_token"
+	CORSWildcardCredentials Code = "cors_wildcard_credentials"
+	CORSBadOrigin           Code = "cors_bad_origin"
+
+	RateLimitBadFormat Code = "rate_limit_bad_format"
+	RateLimitBadCount  Code = "rate_limit_bad_count"
+	RateLimitBadUnit   Code = "rate_limit_bad_unit"
+
+	MailBadRecipient    Code = "mail_bad_recipient"
+	MailBadSender       Code = "mail_bad_sender"
+	MailTemplateMissing Code = "mail_template_missing"
+	MailTemplateFailed  Code = "mail_template_failed"
+	MailNoSTARTTLS      Code = "mail_no_starttls"
+	MailSMTPError       Code = "mail_smtp_error"
+)
+
+var messages = map[Code]map[string]string{
+	InvalidRequest:   {Chinese: "请求参数错误", English: "Invalid request parameters"},
+	InvalidParameter: {Chinese: "%s 参数错误", English: "Invalid %s parameter"},
+	ValidationFailed: {Chinese: "%s", English: "Invalid request: %s"},
+	InternalError:    {Chinese: "服务器内部错误", English: "Internal server error"},
+	Forbidden:        {Chinese: "无权访问", English: "Access denied"},
+	RateLimited:      {Chinese: "请求过于频繁，请稍后再试", English: "Too many requests, please try again later"},
+	BodyTooLarge:     {Chinese: "请求体过大", English: "Request body too large"},
+	APINotFound:      {Chinese: "API路由不存在", English: "API route not found"},
+	FrontendNotFound: {Chinese: "前端资源不存在", English: "Frontend assets not found"},
+
+	Maintenance:         {Chinese: "系统维护中，请稍后再试", English: "The site is under maintenance, please try again later"},
+	ReadOnly:            {Chinese: "站点当前为只读模式，无法上传或修改", English: "The site is read-only; uploads and changes are disabled"},
+	RegistrationClosed:  {Chinese: "管理员已关闭注册", English: "Registration has been closed by the administrator"},
+	GalleryDisabled:     {Chinese: "公开图库已关闭", English: "Public galleries are disabled"},
+	InsufficientStorage: {Chinese: "服务器存储空间不足，暂时无法上传，请联系管理员", English: "The server is low on storage and cannot accept uploads, please contact the administrator"},
+
+	InvalidUploadForm:   {Chinese: "上传表单格式错误", English: "Malformed upload form"},
+	UnsupportedFileType: {Chinese: "不支持的文件类型: %s", English: "Unsupported file type: %s"},
+	FileTooLarge:        {Chinese: "上传文件过大", English: "Uploaded file is too large"},
+
+	QRCodeInvalidURL:    {Chinese: "url 参数应为 http(s) 地址，且不超过 %d 个字符", English: "url must be an http(s) URL of at most %d characters"},
+	QRCodeInvalidSize:   {Chinese: "size 参数应为 %d-%d", English: "size must be between %d and %d"},
+	QRCodeInvalidLevel:  {Chinese: "level 参数应为 low、medium、high 或 highest", English: "level must be low, medium, high or highest"},
+	QRCodeInvalidFormat: {Chinese: "format 参数应为 png 或 svg", English: "format must be png or svg"},
+	QRCodeFailed:        {Chinese: "生成二维码失败", English: "Failed to generate QR code"},
+
+	InvalidRepoName:     {Chinese: "仓库名只能包含字母、数字、'.'、'-' 和 '_'", English: "Repository names may only contain letters, digits, '.', '-' and '_'"},
+	GitHubTokenInvalid:  {Chinese: "GitHub Token 无效", English: "Invalid GitHub token"},
+	GitHubTokenScope:    {Chinese: "GitHub Token 缺少创建仓库所需的 repo 权限", English: "The GitHub token lacks the repo scope required to create repositories"},
+	GitHubRateLimited:   {Chinese: "GitHub 调用额度已用尽，请稍后再试", English: "GitHub rate limit exceeded, please try again later"},
+	RepoUnavailable:     {Chinese: "仓库已存在或名称不可用", English: "The repository already exists or the name is unavailable"},
+	RepoCreateFailed:    {Chinese: "创建仓库失败: %s", English: "Failed to create repository: %s"},
+	RepoCreated:         {Chinese: "仓库创建成功", English: "Repository created"},
+	RepoRenameFailed:    {Chinese: "重命名默认分支失败: %s", English: "Failed to rename the default branch: %s"},
+	RepoGitignoreFailed: {Chinese: "创建 .gitignore 失败: %s", English: "Failed to create .gitignore: %s"},
+	RepoPagesFailed:     {Chinese: "开启 GitHub Pages 失败: %s", English: "Failed to enable GitHub Pages: %s"},
+
+	ConfigInvalid:        {Chinese: "%s", English: "Invalid configuration: %s"},
+	ConfigReloaded:       {Chinese: "配置已重新加载", English: "Configuration reloaded"},
+	SaveSettingsFailed:   {Chinese: "保存系统设置失败", English: "Failed to save system settings"},
+	SettingsSaved:        {Chinese: "系统设置已保存", English: "System settings saved"},
+	DiskMonitorDisabled:  {Chinese: "未启用本地存储磁盘监控", English: "Local storage disk monitoring is not enabled"},
+	MaintenanceNotRun:    {Chinese: "尚未执行过清理", English: "Maintenance has not run yet"},
+	MailRecipientMissing: {Chinese: "请提供收件人地址 to", English: "Recipient address \"to\" is required"},
+	SMTPDisabled:         {Chinese: "未配置 SMTP 服务器", English: "No SMTP server is configured"},
+	MailSendFailed:       {Chinese: "发送邮件失败: %s", English: "Failed to send email: %s"},
+	MailTestSent:         {Chinese: "测试邮件已发送", English: "Test email sent"},
+	DatabaseNotReady:     {Chinese: "数据库未初始化", English: "Database is not initialized"},
+	GitHubRepoDenied:     {Chinese: "无法用配置的令牌访问仓库 %s: %s", English: "Cannot access repository %s with the configured token: %s"},
+
+	JobListFailed:   {Chinese: "获取任务列表失败", English: "Failed to list jobs"},
+	JobCountFailed:  {Chinese: "获取任务统计失败", English: "Failed to count jobs"},
+	JobIDInvalid:    {Chinese: "任务ID错误", English: "Invalid job ID"},
+	JobNotFound:     {Chinese: "任务不存在", English: "Job not found"},
+	JobGetFailed:    {Chinese: "获取任务失败", English: "Failed to load job"},
+	JobNotRetryable: {Chinese: "只能重试失败的任务", English: "Only failed jobs can be retried"},
+	JobRetryFailed:  {Chinese: "重试任务失败", English: "Failed to retry job"},
+	JobRunning:      {Chinese: "任务正在执行，不能删除", English: "The job is running and cannot be deleted"},
+	JobDeleteFailed: {Chinese: "删除任务失败", English: "Failed to delete job"},
+	JobRequeued:     {Chinese: "任务已重新加入队列", English: "Job queued for retry"},
+	JobDeleted:      {Chinese: "任务已删除", English: "Job deleted"},
+
+	AnnouncementGetFailed:      {Chinese: "获取公告失败", English: "Failed to load announcements"},
+	AnnouncementCreateFailed:   {Chinese: "发布公告失败", English: "Failed to publish announcement"},
+	AnnouncementSaveFailed:     {Chinese: "保存公告失败", English: "Failed to save announcement"},
+	AnnouncementDeleteFailed:   {Chinese: "删除公告失败", English: "Failed to delete announcement"},
+	AnnouncementIDInvalid:      {Chinese: "公告ID错误", English: "Invalid announcement ID"},
+	AnnouncementNotFound:       {Chinese: "公告不存在", English: "Announcement not found"},
+	AnnouncementDeleted:        {Chinese: "公告已删除", English: "Announcement deleted"},
+	AnnouncementNotDismissible: {Chinese: "该公告不能关闭", English: "This announcement cannot be dismissed"},
+	AnnouncementDismissFailed:  {Chinese: "关闭公告失败", English: "Failed to dismiss announcement"},
+	AnnouncementDismissed:      {Chinese: "公告已关闭", English: "Announcement dismissed"},
+
+	SettingsBadUploadSize: {Chinese: "单次上传大小上限必须大于 0", English: "max_upload_size_mb must be greater than 0"},
+	SettingsNoFileTypes:   {Chinese: "至少需要允许一种文件类型", English: "At least one file type must be allowed"},
+	SettingsBadFileType:   {Chinese: "文件类型格式错误: %s", English: "Invalid file type: %s"},
+
+	AnnouncementNoTitle:     {Chinese: "公告标题不能为空", English: "Announcement title is required"},
+	AnnouncementBadLevel:    {Chinese: "公告级别只能是 info/warning/critical", English: "Announcement level must be info, warning or critical"},
+	AnnouncementBadAudience: {Chinese: "公告对象只能是 all/users/public", English: "Announcement audience must be all, users or public"},
+	AnnouncementBadPeriod:   {Chinese: "结束时间必须晚于开始时间", English: "The end time must be after the start time"},
+
+	ConfigReadFailed:        {Chinese: "读取配置文件失败: %s", English: "Failed to read config file: %s"},
+	ConfigParseFailed:       {Chinese: "解析配置文件 %s 失败: %s", English: "Failed to parse config file %s: %s"},
+	ConfigBadDuration:       {Chinese: "第 %d 行: 时长格式错误 %q", English: "line %d: invalid duration %q"},
+	ConfigBadEnv:            {Chinese: "环境变量 %s 格式错误: %q", English: "Invalid value for environment variable %s: %q"},
+	ConfigBadFlag:           {Chinese: "参数 %s 格式错误: %q", English: "Invalid value for flag %s: %q"},
+	ConfigField:             {Chinese: "%s: %s", English: "%s: %s"},
+	ConfigBadValue:          {Chinese: "%s 格式错误: %q", English: "Invalid %s: %q"},
+	ConfigBadListen:         {Chinese: "server.listen 格式错误（应为 host:port、:port、unix:/path 或 systemd）: %q", English: "Invalid server.listen (expected host:port, :port, unix:/path or systemd): %q"},
+	ConfigNoSocketPath:      {Chinese: "server.listen 缺少 unix socket 路径", English: "server.listen is missing the unix socket path"},
+	ConfigBadAddr:           {Chinese: "%s 格式错误（应为 host:port 或 :port）: %q", English: "Invalid %s (expected host:port or :port): %q"},
+	ConfigNotOneOf:          {Chinese: "%s 只能是 %s: %q", English: "%s must be one of %s: %q"},
+	ConfigNegative:          {Chinese: "%s 不能为负数", English: "%s must not be negative"},
+	ConfigNotPositive:       {Chinese: "%s 必须大于 0", English: "%s must be greater than 0"},
+	ConfigTLSPair:           {Chinese: "tls.cert_file 和 tls.key_file 必须同时配置", English: "tls.cert_file and tls.key_file must be set together"},
+	ConfigTLSExclusive:      {Chinese: "tls: 证书文件与 autocert_domains 只能选择一种", English: "tls: use either certificate files or autocert_domains, not both"},
+	ConfigTLSRedirect:       {Chinese: "tls.redirect_http 需要先启用 HTTPS", English: "tls.redirect_http requires HTTPS to be enabled"},
+	ConfigCompression:       {Chinese: "compression: gzip_level 应为 1-9，brotli_level 应为 0-11，min_size 不能为负数", English: "compression: gzip_level must be 1-9, brotli_level 0-11 and min_size must not be negative"},
+	ConfigGitHubToken:       {Chinese: "github.health_repo 需要同时配置 github.token", English: "github.health_repo requires github.token"},
+	CORSWildcardCredentials: {Chinese: "cors: 允许所有来源时不能同时允许携带凭据", English: "cors: credentials cannot be allowed together with all origins"},
+	CORSBadOrigin:           {Chinese: "cors: 来源格式错误（应为 scheme://host[:port]）: %s", English: "cors: invalid origin (expected scheme://host[:port]): %s"},
+
+	RateLimitBadFormat: {Chinese: "限流配置格式应为 N/s、N/m、N/h 或 N/d: %q", English: "Rate limits must be written as N/s, N/m, N/h or N/d: %q"},
+	RateLimitBadCount:  {Chinese: "限流次数必须为正整数: %q", English: "The rate limit count must be a positive integer: %q"},
+	RateLimitBadUnit:   {Chinese: "未知的限流时间单位: %q", English: "Unknown rate limit unit: %q"},
+
+	MailBadRecipient:    {Chinese: "收件人地址格式错误: %s", English: "Invalid recipient address: %s"},
+	MailBadSender:       {Chinese: "smtp.from 格式错误: %s", English: "Invalid smtp.from: %s"},
+	MailTemplateMissing: {Chinese: "邮件模板不存在: %s", English: "Unknown email template: %s"},
+	MailTemplateFailed:  {Chinese: "渲染邮件模板 %s 失败: %s", English: "Failed to render email template %s: %s"},
+	MailNoSTARTTLS:      {Chinese: "SMTP 服务器不支持 STARTTLS", English: "The SMTP server does not support STARTTLS"},
+	MailSMTPError:       {Chinese: "SMTP 服务器错误: %s", English: "SMTP server error: %s"},
+}
@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 任务状态
+const (
+	StatusPending = "pending" // 等待执行（包括等待重试）
+	StatusRunning = "running" // 某个实例正在执行
+	StatusDone    = "done"
+	StatusFailed  = "failed" // 重试次数用完，需要管理员处理
+)
+
+// DefaultMaxAttempts 默认最多执行次数
+const DefaultMaxAttempts = 5
+
+// Job 持久化的后台任务，多实例部署时由任一实例的 worker 领取执行
+type Job struct {
+	ID   uint   `gorm:"primaryKey" json:"id"`
+	Type string `gorm:"size:64;not null;index:idx_jobs_poll,priority:2" json:"type"`
+	// Payload 任务参数（JSON）
+	Payload     string `gorm:"type:text" json:"payload"`
+	Status      string `gorm:"size:16;not null;index:idx_jobs_poll,priority:1" json:"status"`
+	Attempts    int    `json:"attempts"`
+	MaxAttempts int    `json:"max_attempts"`
+	// RunAt 最早执行时间，失败重试时按退避时间后移
+	RunAt      time.Time  `gorm:"index:idx_jobs_poll,priority:3" json:"run_at"`
+	LockedBy   string     `gorm:"size:100" json:"locked_by,omitempty"`
+	LockedAt   *time.Time `json:"locked_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	LastError  string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Decode 解析任务参数
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// Handler 执行一种任务，返回错误时按退避时间重试。ctx 在服务器关闭时取消。
+type Handler func(ctx context.Context, job *Job) error
+
+var (
+	db *gorm.DB
+
+	handlersMu sync.RWMutex
+	handlers   = map[string]Handler{}
+)
+
+// Init 建表
+func Init(database *gorm.DB) error {
+	db = database
+	return db.AutoMigrate(&Job{})
+}
+
+// Register 注册任务类型的处理函数，需在 Start 之前调用
+func Register(jobType string, h Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[jobType] = h
+}
+
+func handlerFor(jobType string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[jobType]
+	return h, ok
+}
+
+func registeredTypes() []string {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	types := make([]string, 0, len(handlers))
+	for t := range handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Enqueue 加入一个立即执行的任务
+func Enqueue(ctx context.Context, jobType string, payload interface{}) (*Job, error) {
+	return EnqueueAt(ctx, jobType, payload, time.Now())
+}
+
+// EnqueueAt 加入一个在 runAt 之后执行的任务
+func EnqueueAt(ctx context.Context, jobType string, payload interface{}, runAt time.Time) (*Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("任务参数无法编码: %w", err)
+	}
+	job := &Job{
+		Type:        jobType,
+		Payload:     string(raw),
+		Status:      StatusPending,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       runAt,
+	}
+	if err := db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// List 按创建时间倒序列出任务，status/jobType 为空时不过滤
+func List(ctx context.Context, status, jobType string, limit int) ([]Job, error) {
+	q := db.WithContext(ctx).Order("id DESC").Limit(limit)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if jobType != "" {
+		q = q.Where("type = ?", jobType)
+	}
+	var list []Job
+	err := q.Find(&list).Error
+	return list, err
+}
+
+// Counts 各状态的任务数
+func Counts(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := db.WithContext(ctx).Model(&Job{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error
+	counts := map[string]int64{StatusPending: 0, StatusRunning: 0, StatusDone: 0, StatusFailed: 0}
+	for _, r := range rows {
+		counts[r.Status] = r.Count
+	}
+	return counts, err
+}
+
+// Get 按 ID 查询任务
+func Get(ctx context.Context, id uint) (*Job, error) {
+	var job Job
+	if err := db.WithContext(ctx).First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ErrNotRetryable 只有失败的任务可以手动重试
+var ErrNotRetryable = errors.New("只能重试失败的任务")
+
+// ErrRunning 正在执行的任务不能删除
+var ErrRunning = errors.New("任务正在执行，不能删除")
+
+// Retry 将失败的任务重新放回队列，重新计算重试次数
+func Retry(ctx context.Context, id uint) error {
+	res := db.WithContext(ctx).Model(&Job{}).
+		Where("id = ? AND status = ?", id, StatusFailed).
+		Updates(map[string]interface{}{
+			"status":      StatusPending,
+			"attempts":    0,
+			"run_at":      time.Now(),
+			"finished_at": nil,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotRetryable
+	}
+	return nil
+}
+
+// Delete 删除未在执行的任务
+func Delete(ctx context.Context, id uint) error {
+	res := db.WithContext(ctx).Where("id = ? AND status <> ?", id, StatusRunning).Delete(&Job{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRunning
+	}
+	return nil
+}
+
+// PurgeFinished 删除 before 之前完成的任务，dryRun 时只返回数量
+func PurgeFinished(ctx context.Context, before time.Time, dryRun bool) (int64, error) {
+	q := db.WithContext(ctx).Model(&Job{}).Where("status = ? AND finished_at < ?", StatusDone, before)
+	if dryRun {
+		var n int64
+		err := q.Count(&n).Error
+		return n, err
+	}
+	res := q.Delete(&Job{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,240 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupDB(t *testing.T) {
+	t.Helper()
+	database, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, _ := database.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := Init(database); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustGet(t *testing.T, id uint) *Job {
+	t.Helper()
+	job, err := Get(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func mustClaim(t *testing.T) *Job {
+	t.Helper()
+	job, err := claim(context.Background())
+	if err != nil || job == nil {
+		t.Fatalf("claim = %v, %v", job, err)
+	}
+	return job
+}
+
+func TestRunOutcome(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		maxAttempts int
+		handler     Handler
+		status      string
+		lastError   string
+	}{
+		{"success", 3, func(context.Context, *Job) error { return nil }, StatusDone, ""},
+		{"retry", 3, func(context.Context, *Job) error { return errors.New("boom") }, StatusPending, "boom"},
+		{"attempts exhausted", 1, func(context.Context, *Job) error { return errors.New("boom") }, StatusFailed, "boom"},
+		{"panic retried", 3, func(context.Context, *Job) error { panic("kaboom") }, StatusPending, "panic: kaboom"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setupDB(t)
+			Register("test", tc.handler)
+			ctx := context.Background()
+			queued, err := Enqueue(ctx, "test", map[string]int{"n": 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			db.Model(queued).Update("max_attempts", tc.maxAttempts)
+
+			job := mustClaim(t)
+			if got := mustGet(t, job.ID); got.Status != StatusRunning || got.Attempts != 1 || got.LockedBy == "" {
+				t.Fatalf("领取后任务为 %+v", got)
+			}
+			if again, _ := claim(ctx); again != nil {
+				t.Fatalf("running 任务被再次领取: %+v", again)
+			}
+
+			run(ctx, job)
+			got := mustGet(t, job.ID)
+			if got.Status != tc.status {
+				t.Fatalf("状态 = %s, 期望 %s", got.Status, tc.status)
+			}
+			if !strings.HasPrefix(got.LastError, tc.lastError) {
+				t.Fatalf("last_error = %q, 期望以 %q 开头", got.LastError, tc.lastError)
+			}
+			if got.LockedBy != "" || got.LockedAt != nil {
+				t.Fatalf("执行结束后未释放锁: %+v", got)
+			}
+			if (tc.status == StatusPending) != got.RunAt.After(time.Now()) {
+				t.Fatalf("run_at = %v，重试任务应后移、其它状态不变", got.RunAt)
+			}
+			if (got.FinishedAt != nil) != (tc.status != StatusPending) {
+				t.Fatalf("finished_at = %v", got.FinishedAt)
+			}
+		})
+	}
+}
+
+// 服务器关闭取消了执行：任务放回队列，不计次数、不退避，次数耗尽也不会标记为失败
+func TestRunShutdown(t *testing.T) {
+	setupDB(t)
+	started := make(chan struct{})
+	Register("test", func(ctx context.Context, _ *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	queued, err := Enqueue(ctx, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Model(queued).Update("max_attempts", 1)
+
+	job := mustClaim(t)
+	go func() {
+		<-started
+		cancel()
+	}()
+	run(ctx, job)
+
+	got := mustGet(t, job.ID)
+	if got.Status != StatusPending || got.Attempts != 0 || got.LastError != "" || got.LockedBy != "" {
+		t.Fatalf("关闭中断后任务为 %+v", got)
+	}
+	if got.RunAt.After(time.Now()) {
+		t.Fatalf("run_at = %v，中断的任务不应退避", got.RunAt)
+	}
+	if again := mustClaim(t); again.ID != job.ID {
+		t.Fatalf("重新领取到 %+v", again)
+	}
+}
+
+func TestStaleLock(t *testing.T) {
+	setupDB(t)
+	Register("test", func(context.Context, *Job) error { return nil })
+	ctx := context.Background()
+	if _, err := Enqueue(ctx, "test", nil); err != nil {
+		t.Fatal(err)
+	}
+	first := mustClaim(t)
+
+	// 心跳刷新 locked_at，任务不会被当作超时回收
+	db.Model(&Job{}).Where("id = ?", first.ID).Update("locked_at", time.Now().Add(-2*staleAfter))
+	if ok, err := touchLock(ctx, first); !ok || err != nil {
+		t.Fatalf("touchLock = %v, %v", ok, err)
+	}
+	requeueStale(ctx)
+	if got := mustGet(t, first.ID); got.Status != StatusRunning || got.LockedBy != first.LockedBy {
+		t.Fatalf("有心跳的任务被回收: %+v", got)
+	}
+
+	// 心跳中断超过 staleAfter 后被回收，并由另一个 worker 重新领取
+	db.Model(&Job{}).Where("id = ?", first.ID).Update("locked_at", time.Now().Add(-2*staleAfter))
+	requeueStale(ctx)
+	second := mustClaim(t)
+	if second.ID != first.ID || second.LockedBy == first.LockedBy {
+		t.Fatalf("重新领取的任务 %+v，原任务 %+v", second, first)
+	}
+
+	// 旧的执行既不能续期，也不能覆盖新执行的状态
+	if ok, _ := touchLock(ctx, first); ok {
+		t.Fatal("被回收的锁仍能续期")
+	}
+	finish(first, errors.New("stale"), time.Second)
+	if got := mustGet(t, first.ID); got.Status != StatusRunning || got.LockedBy != second.LockedBy || got.LastError != "" {
+		t.Fatalf("旧执行覆盖了任务状态: %+v", got)
+	}
+
+	finish(second, nil, time.Second)
+	if got := mustGet(t, first.ID); got.Status != StatusDone || got.Attempts != 2 {
+		t.Fatalf("新执行完成后任务为 %+v", got)
+	}
+}
+
+// 次数用完的超时任务（例如每次执行都让进程崩溃）标记为失败，不再放回队列
+func TestStaleLockExhausted(t *testing.T) {
+	setupDB(t)
+	Register("test", func(context.Context, *Job) error { return nil })
+	ctx := context.Background()
+	queued, err := Enqueue(ctx, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Model(queued).Update("max_attempts", 1)
+	job := mustClaim(t)
+
+	db.Model(&Job{}).Where("id = ?", job.ID).Update("locked_at", time.Now().Add(-2*staleAfter))
+	requeueStale(ctx)
+	got := mustGet(t, job.ID)
+	if got.Status != StatusFailed || got.LastError != staleError || got.FinishedAt == nil || got.LockedBy != "" {
+		t.Fatalf("次数用完的超时任务为 %+v", got)
+	}
+	if again, _ := claim(ctx); again != nil {
+		t.Fatalf("失败的任务被再次领取: %+v", again)
+	}
+}
+
+func TestRetryAndDelete(t *testing.T) {
+	setupDB(t)
+	Register("test", func(context.Context, *Job) error { return errors.New("boom") })
+	ctx := context.Background()
+	queued, err := Enqueue(ctx, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Model(queued).Update("max_attempts", 1)
+
+	if err := Retry(ctx, queued.ID); !errors.Is(err, ErrNotRetryable) {
+		t.Fatalf("重试 pending 任务: %v", err)
+	}
+	job := mustClaim(t)
+	if err := Delete(ctx, job.ID); !errors.Is(err, ErrRunning) {
+		t.Fatalf("删除 running 任务: %v", err)
+	}
+	run(ctx, job)
+	if err := Retry(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustGet(t, job.ID); got.Status != StatusPending || got.Attempts != 0 || got.FinishedAt != nil {
+		t.Fatalf("重试后任务为 %+v", got)
+	}
+	if err := Delete(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, maxBackoff},
+	} {
+		if got := backoff(tc.attempt); got != tc.want {
+			t.Errorf("backoff(%d) = %v, 期望 %v", tc.attempt, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"pic/cluster"
+	"pic/metrics"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// staleAfter running 任务超过该时间没有心跳视为执行它的实例已崩溃，任务重新放回队列
+	staleAfter = 15 * time.Minute
+	// heartbeatInterval 执行期间刷新 locked_at 的间隔，须远小于 staleAfter
+	heartbeatInterval = time.Minute
+	// maxBackoff 重试间隔上限
+	maxBackoff = time.Hour
+)
+
+// Start 启动 n 个 worker 轮询任务，n 为 0 时本实例只入队不执行。
+// 返回的函数等待 worker 退出（ctx 取消后正在执行的任务结束时）。
+func Start(ctx context.Context, n int, pollInterval time.Duration) (wait func()) {
+	var wg sync.WaitGroup
+	if n <= 0 {
+		return wg.Wait
+	}
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work(ctx, pollInterval)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				requeueStale(ctx)
+			}
+		}
+	}()
+
+	slog.Info("⚙️ 后台任务 worker 已启动", "workers", n, "types", registeredTypes())
+	return wg.Wait
+}
+
+func work(ctx context.Context, pollInterval time.Duration) {
+	for {
+		job, err := claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("领取后台任务失败", "error", err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+		run(ctx, job)
+	}
+}
+
+// claimSeq 区分本实例的每次领取，任务被回收后再次由本实例领取时旧的执行也无法写回结果
+var claimSeq atomic.Uint64
+
+// claim 领取一个到期的任务。先查询再按状态条件更新，多个实例同时领取同一任务时只有一个成功。
+func claim(ctx context.Context) (*Job, error) {
+	types := registeredTypes()
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	for {
+		now := time.Now()
+		owner := fmt.Sprintf("%s/%d", cluster.InstanceID(), claimSeq.Add(1))
+		var job Job
+		err := db.WithContext(ctx).
+			Where("status = ? AND type IN ? AND run_at <= ?", StatusPending, types, now).
+			Order("run_at").
+			Take(&job).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		res := db.WithContext(ctx).Model(&Job{}).
+			Where("id = ? AND status = ?", job.ID, StatusPending).
+			Updates(map[string]interface{}{
+				"status":    StatusRunning,
+				"attempts":  gorm.Expr("attempts + 1"),
+				"locked_by": owner,
+				"locked_at": now,
+			})
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 1 {
+			job.Status, job.Attempts, job.LockedBy, job.LockedAt = StatusRunning, job.Attempts+1, owner, &now
+			return &job, nil
+		}
+		// 被其它 worker 抢先领取，继续找下一个
+	}
+}
+
+func run(ctx context.Context, job *Job) {
+	h, _ := handlerFor(job.Type)
+	start := time.Now()
+
+	// 执行期间定时续期，锁被回收（心跳中断超过 staleAfter）时取消处理函数
+	jobCtx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		heartbeat(jobCtx, job, cancel)
+	}()
+	err := safeCall(jobCtx, h, job)
+	cancel()
+	<-stopped
+
+	if err != nil && ctx.Err() != nil {
+		// 服务器关闭中断了执行，不算一次失败
+		release(job)
+		return
+	}
+	finish(job, err, time.Since(start))
+}
+
+// release 把因服务器关闭而中断的任务放回队列：撤销领取时计入的次数，不退避，由下一个实例立即领取
+func release(job *Job) {
+	res := db.Model(&Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, StatusRunning, job.LockedBy).
+		Updates(map[string]interface{}{
+			"status":    StatusPending,
+			"attempts":  gorm.Expr("attempts - 1"),
+			"locked_by": "",
+			"locked_at": nil,
+		})
+	if res.Error != nil {
+		slog.Error("释放后台任务失败", "job_id", job.ID, "error", res.Error)
+		return
+	}
+	if res.RowsAffected > 0 {
+		slog.Info("后台任务因服务器关闭中断，已放回队列", "job_id", job.ID, "type", job.Type)
+	}
+}
+
+// heartbeat 每 heartbeatInterval 刷新一次任务的 locked_at，直到 ctx 结束或锁已不属于本次执行
+func heartbeat(ctx context.Context, job *Job, lost context.CancelFunc) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := touchLock(ctx, job)
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("刷新后台任务锁失败", "job_id", job.ID, "error", err)
+				}
+				continue
+			}
+			if !ok {
+				slog.Warn("⚠️ 后台任务锁已被回收，停止执行", "job_id", job.ID, "type", job.Type)
+				lost()
+				return
+			}
+		}
+	}
+}
+
+// touchLock 刷新本次执行持有的锁，返回 false 表示任务已被回收或由其它 worker 领取
+func touchLock(ctx context.Context, job *Job) (bool, error) {
+	res := db.WithContext(ctx).Model(&Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, StatusRunning, job.LockedBy).
+		Update("locked_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// finish 按执行结果写回任务状态，只在锁仍属于本次执行时生效
+func finish(job *Job, err error, duration time.Duration) {
+	log := slog.With("job_id", job.ID, "type", job.Type, "attempt", job.Attempts, "duration_ms", duration.Milliseconds())
+
+	now := time.Now()
+	updates := map[string]interface{}{"locked_by": "", "locked_at": nil}
+	var outcome string
+	switch {
+	case err == nil:
+		outcome = "done"
+		updates["status"], updates["finished_at"], updates["last_error"] = StatusDone, now, ""
+	case job.Attempts >= job.MaxAttempts:
+		outcome = "failed"
+		updates["status"], updates["finished_at"], updates["last_error"] = StatusFailed, now, err.Error()
+	default:
+		outcome = "retry"
+		updates["status"], updates["run_at"], updates["last_error"] = StatusPending, now.Add(backoff(job.Attempts)), err.Error()
+	}
+
+	// 服务器关闭时 ctx 已取消，状态仍需写回
+	res := db.Model(&Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, StatusRunning, job.LockedBy).
+		Updates(updates)
+	if res.Error != nil {
+		log.Error("保存后台任务状态失败", "error", res.Error)
+		return
+	}
+	if res.RowsAffected == 0 {
+		log.Warn("⚠️ 后台任务已被回收，丢弃本次执行结果", "error", err)
+		return
+	}
+
+	metrics.JobsTotal.WithLabelValues(job.Type, outcome).Inc()
+	switch outcome {
+	case "done":
+		log.Debug("后台任务完成")
+	case "failed":
+		log.Error("后台任务失败，不再重试", "error", err)
+	default:
+		log.Warn("后台任务失败，稍后重试", "error", err)
+	}
+}
+
+// safeCall 执行处理函数，把 panic 转为错误，避免一个任务拖垮 worker
+func safeCall(ctx context.Context, h Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return h(ctx, job)
+}
+
+// backoff 第 attempt 次失败后的重试间隔：30s、1m、2m……最多 1 小时
+func backoff(attempt int) time.Duration {
+	d := 30 * time.Second
+	for i := 1; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// staleError 次数用完的超时任务记录的错误，执行中进程崩溃或被杀时任务只会超时，没有处理函数返回的错误
+const staleError = "执行超时（心跳中断），重试次数已用完"
+
+// requeueStale 将长时间没有心跳的 running 任务放回队列；领取时已计入次数，
+// 次数用完的直接标记为失败，避免每次都让进程崩溃的任务被无限次领取
+func requeueStale(ctx context.Context) {
+	exhausted := "attempts >= max_attempts"
+	res := db.WithContext(ctx).Model(&Job{}).
+		Where("status = ? AND locked_at < ?", StatusRunning, time.Now().Add(-staleAfter)).
+		Updates(map[string]interface{}{
+			"status":      gorm.Expr("CASE WHEN "+exhausted+" THEN ? ELSE ? END", StatusFailed, StatusPending),
+			"finished_at": gorm.Expr("CASE WHEN "+exhausted+" THEN ? ELSE finished_at END", time.Now()),
+			"last_error":  gorm.Expr("CASE WHEN "+exhausted+" THEN ? ELSE last_error END", staleError),
+			"locked_by":   "",
+			"locked_at":   nil,
+		})
+	if res.Error != nil {
+		slog.Warn("回收超时任务失败", "error", res.Error)
+	} else if res.RowsAffected > 0 {
+		slog.Warn("⚠️ 回收了执行超时的后台任务", "count", res.RowsAffected)
+	}
+}
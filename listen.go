@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"pic/middleware"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listenFDsStart systemd 传入的第一个文件描述符
+const listenFDsStart = 3
+
+// listen 按 server.listen 创建监听：TCP 地址、unix:/path 或 systemd socket 激活
+func listen(addr string) (net.Listener, error) {
+	switch {
+	case addr == "systemd":
+		return systemdListener()
+	case strings.HasPrefix(addr, "unix:"):
+		path := strings.TrimPrefix(addr, "unix:")
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		// 与监听 127.0.0.1 相同，本机的反向代理（nginx/caddy 等）都能连接
+		if err := os.Chmod(path, 0o666); err != nil {
+			ln.Close()
+			return nil, err
+		}
+		return ln, nil
+	default:
+		return net.Listen("tcp", addr)
+	}
+}
+
+// removeStaleSocket 清理上次异常退出残留的 socket 文件。路径上是普通文件等非 socket 时不删除，
+// 仍能连上（另一个实例正在监听）时也不删除，以免抢走正在运行的实例的监听。
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s 已存在且不是 socket 文件", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s 上已有进程在监听", path)
+	}
+	return os.Remove(path)
+}
+
+// systemdListener 取得 systemd socket 激活传入的第一个监听（LISTEN_PID/LISTEN_FDS 协议）
+func systemdListener() (net.Listener, error) {
+	pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	fds, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if pid != os.Getpid() || fds < 1 {
+		return nil, errors.New("没有收到 systemd 传入的监听（需要配合 .socket 单元启动）")
+	}
+	// 避免子进程误用
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	f := os.NewFile(listenFDsStart, "systemd-socket")
+	defer f.Close()
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("systemd 传入的文件描述符不是监听 socket: %w", err)
+	}
+	return ln, nil
+}
+
+// unixRemoteAddr unix socket 连接没有对端 IP，将其视为来自 127.0.0.1 的 TCP 连接，
+// 使限流按本机处理，是否信任前面反向代理的代理头同样由 trusted_proxies 决定。
+// 请求同时被标记为来自 unix socket，管理接口和 /metrics 不会因此按本机 IP 放行。
+func unixRemoteAddr(h http.Handler) http.Handler {
+	loopback := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, err := net.SplitHostPort(r.RemoteAddr); err != nil {
+			r.RemoteAddr = "127.0.0.1:0"
+		}
+		// gin 对 unix socket 一律信任代理头，这里替换本地地址，改为按 trusted_proxies 判断
+		r = r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey, loopback))
+		h.ServeHTTP(w, middleware.MarkUnixSocket(r))
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	dir := t.TempDir()
+
+	// 普通文件不能被当作残留的 socket 删除
+	file := filepath.Join(dir, "file.sock")
+	if err := os.WriteFile(file, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listen("unix:" + file); err == nil {
+		t.Fatal("路径是普通文件时应当报错")
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("普通文件被删除: %v", err)
+	}
+
+	// 正在监听的 socket 属于另一个实例，不能抢占
+	path := filepath.Join(dir, "pic.sock")
+	ln, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listen("unix:" + path); err == nil {
+		t.Fatal("socket 正在被监听时应当报错")
+	}
+	if conn, err := net.Dial("unix", path); err != nil {
+		t.Fatalf("原有监听被破坏: %v", err)
+	} else {
+		conn.Close()
+	}
+
+	// 进程退出后残留的 socket 文件可以重新使用
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+	if _, err := os.Lstat(path); err != nil {
+		t.Fatalf("残留 socket 文件不存在: %v", err)
+	}
+	ln, err = listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("残留的 socket 文件应被清理: %v", err)
+	}
+	ln.Close()
+}
@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type ctxKey struct{}
+
+const requestIDKey = "request_id"
+
+var level slog.LevelVar
+
+// Init 按格式（json/text）和级别（debug/info/warn/error）设置全局 slog 日志。
+// 标准库 log 包的输出也会经过这里，已有的 log.Println 同样变为结构化日志。
+func Init(levelName, format string) {
+	SetLevel(levelName)
+	opts := &slog.HandlerOptions{Level: &level}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "text") {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
+
+// SetLevel 运行时修改日志级别，无需重新创建日志对象
+func SetLevel(levelName string) {
+	level.Set(parseLevel(levelName))
+}
+
+func parseLevel(s string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithRequestID 返回携带请求 ID 的 context，之后 FromContext 得到的日志都会带上该 ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	l := FromContext(ctx).With(requestIDKey, requestID)
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext 返回 context 中的日志对象，没有时返回全局默认日志
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupLayout 旧文件名中的时间戳格式
+const backupLayout = "20060102-150405.000"
+
+// RotatingFile 按大小和/或时间切割的日志文件，切割后的旧文件命名为 <path>.<时间戳>
+type RotatingFile struct {
+	Path string
+	// MaxSize 单个文件的最大字节数，0 表示不按大小切割
+	MaxSize int64
+	// Interval 按时间切割的周期（如 24h），0 表示不按时间切割
+	Interval time.Duration
+	// MaxBackups 保留的旧文件数量，0 表示全部保留
+	MaxBackups int
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// OpenRotatingFile 创建目录并打开（追加）日志文件
+func OpenRotatingFile(path string, maxSize int64, interval time.Duration, maxBackups int) (*RotatingFile, error) {
+	rf := &RotatingFile{Path: path, MaxSize: maxSize, Interval: interval, MaxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	f, err := os.OpenFile(rf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.file, rf.size = f, info.Size()
+	rf.openedAt = time.Now()
+	if rf.size > 0 {
+		// 沿用已有文件时，以文件修改时间作为周期起点
+		rf.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+// Write 实现 io.Writer，写入前按需切割
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.shouldRotate(int64(len(p))) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *RotatingFile) shouldRotate(next int64) bool {
+	if rf.size == 0 {
+		return false
+	}
+	if rf.MaxSize > 0 && rf.size+next > rf.MaxSize {
+		return true
+	}
+	if rf.Interval > 0 {
+		return !periodStart(rf.openedAt, rf.Interval).Equal(periodStart(time.Now(), rf.Interval))
+	}
+	return false
+}
+
+// periodStart 返回 t 所在切割周期的起点。time.Truncate 以 UTC 计算，
+// 这里先换算成 t 所在时区的墙上时间，使按天切割发生在本地零点
+func periodStart(t time.Time, interval time.Duration) time.Time {
+	_, offset := t.Zone()
+	return t.Add(time.Duration(offset) * time.Second).Truncate(interval)
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	backup := fmt.Sprintf("%s.%s", rf.Path, time.Now().Format(backupLayout))
+	if err := os.Rename(rf.Path, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := rf.open(); err != nil {
+		rf.file = nil
+		return err
+	}
+	rf.openedAt = time.Now()
+	rf.prune()
+	return nil
+}
+
+// prune 删除超出 MaxBackups 的旧文件（时间戳命名，按名称排序即按时间排序）。
+// 只处理 <path>.<时间戳> 形式的文件，同目录下其他以 <path>. 开头的文件不受影响
+func (rf *RotatingFile) prune() {
+	if rf.MaxBackups <= 0 {
+		return
+	}
+	dir, prefix := filepath.Dir(rf.Path), filepath.Base(rf.Path)+"."
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var backups []string
+	for _, entry := range entries {
+		stamp, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		if _, err := time.Parse(backupLayout, stamp); err == nil {
+			backups = append(backups, filepath.Join(dir, entry.Name()))
+		}
+	}
+	if len(backups) <= rf.MaxBackups {
+		return
+	}
+	sort.Strings(backups)
+	for _, old := range backups[:len(backups)-rf.MaxBackups] {
+		_ = os.Remove(old)
+	}
+}
+
+// Close 关闭当前文件
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	// UTC+8：UTC 零点是本地 08:00，按天切割不应发生在这里
+	cst := time.FixedZone("CST", 8*3600)
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, cst) }
+	for _, tc := range []struct {
+		name     string
+		a, b     time.Time
+		interval time.Duration
+		same     bool
+	}{
+		{"across utc midnight", at(14, 7, 59), at(14, 8, 1), 24 * time.Hour, true},
+		{"across local midnight", at(14, 23, 59), at(15, 0, 1), 24 * time.Hour, false},
+		{"same hour", at(14, 9, 0), at(14, 9, 59), time.Hour, true},
+		{"next hour", at(14, 9, 59), at(14, 10, 0), time.Hour, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := periodStart(tc.a, tc.interval).Equal(periodStart(tc.b, tc.interval)); got != tc.same {
+				t.Fatalf("%v 与 %v 同一周期 = %v，期望 %v", tc.a, tc.b, got, tc.same)
+			}
+		})
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	files := []string{
+		"access.log.20261012-000000.000",
+		"access.log.20261013-000000.000",
+		"access.log.20261014-000000.000",
+		// 以下文件名不是切割产生的，不应被删除
+		"access.log.bak",
+		"access.log.20261011.gz",
+		"access.log.old.20261010-000000.000",
+		"error.log.20261010-000000.000",
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rf := &RotatingFile{Path: path, MaxBackups: 2}
+	rf.prune()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	want := slices.Clone(files[1:])
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("剩余文件 = %v，期望 %v", got, want)
+	}
+}
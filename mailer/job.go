@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"pic/i18n"
+	"pic/jobs"
+)
+
+// JobType 异步发送邮件的后台任务类型，投递失败时按任务队列的退避时间重试
+const JobType = "mail.send"
+
+type jobPayload struct {
+	To       string                 `json:"to"`
+	Template string                 `json:"template"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// Enqueue 将邮件放入后台任务队列，由任一实例的 worker 发送。
+// data 在入队时编码为 JSON，模板中按字段名访问。
+func Enqueue(ctx context.Context, to, name string, data map[string]interface{}) (*jobs.Job, error) {
+	if _, ok := templates[name]; !ok {
+		return nil, i18n.NewError(i18n.MailTemplateMissing, name)
+	}
+	return jobs.Enqueue(ctx, JobType, jobPayload{To: to, Template: name, Data: data})
+}
+
+// HandleJob 执行 JobType 任务，需通过 jobs.Register 注册
+func HandleJob(ctx context.Context, job *jobs.Job) error {
+	var p jobPayload
+	if err := job.Decode(&p); err != nil {
+		return fmt.Errorf("邮件任务参数错误: %w", err)
+	}
+	return Send(ctx, p.To, p.Template, p.Data)
+}
@@ -0,0 +1,210 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"pic/config"
+	"pic/i18n"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 内置邮件模板，数据字段见 templates/ 下对应文件
+const (
+	TemplateTest          = "test"
+	TemplatePasswordReset = "password_reset" // Username、ResetURL、ExpiresIn
+	TemplateVerification  = "verification"   // Username、VerifyURL
+	TemplateInvitation    = "invitation"     // Inviter、InviteURL、ExpiresIn
+	TemplateNotification  = "notification"   // Title、Message、URL
+)
+
+// sendTimeout ctx 没有截止时间时，单封邮件从连接到发送完成的最长时间
+const sendTimeout = 30 * time.Second
+
+// ErrDisabled 未配置 SMTP 服务器
+var ErrDisabled = i18n.NewError(i18n.SMTPDisabled)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// templates 模板名 -> 模板，每个模板定义 subject 和 body 两部分
+var templates = func() map[string]*template.Template {
+	files, err := templateFS.ReadDir("templates")
+	if err != nil {
+		panic(err)
+	}
+	m := make(map[string]*template.Template, len(files))
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), ".tmpl")
+		m[name] = template.Must(template.ParseFS(templateFS, "templates/"+f.Name()))
+	}
+	return m
+}()
+
+// Enabled 当前配置是否可以发送邮件
+func Enabled() bool {
+	return config.Current().SMTP.Enabled()
+}
+
+// Send 用模板 name 渲染邮件并通过当前配置的 SMTP 服务器发给 to。
+// SMTP 配置在每次发送时读取，热加载后立即生效。
+func Send(ctx context.Context, to, name string, data interface{}) error {
+	cfg := config.Current().SMTP
+	if !cfg.Enabled() {
+		return ErrDisabled
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return i18n.NewError(i18n.MailBadRecipient, err)
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return i18n.NewError(i18n.MailBadSender, err)
+	}
+
+	subject, body, err := render(name, data)
+	if err != nil {
+		return err
+	}
+	msg, err := buildMessage(from, rcpt, subject, body)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sendTimeout)
+		defer cancel()
+	}
+	if err := deliver(ctx, cfg, from.Address, rcpt.Address, msg); err != nil {
+		return i18n.NewError(i18n.MailSMTPError, err)
+	}
+	slog.Debug("📧 邮件已发送", "template", name)
+	return nil
+}
+
+// render 渲染模板的标题和正文
+func render(name string, data interface{}) (subject, body string, err error) {
+	t, ok := templates[name]
+	if !ok {
+		return "", "", i18n.NewError(i18n.MailTemplateMissing, name)
+	}
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return "", "", i18n.NewError(i18n.MailTemplateFailed, name, err)
+	}
+	// 标题中的换行会被当作新的邮件头
+	subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	if err := t.ExecuteTemplate(&buf, "body", data); err != nil {
+		return "", "", i18n.NewError(i18n.MailTemplateFailed, name, err)
+	}
+	return subject, strings.TrimSpace(buf.String()) + "\n", nil
+}
+
+// buildMessage 组装纯文本邮件，正文使用 UTF-8 quoted-printable 编码
+func buildMessage(from, to *mail.Address, subject, body string) ([]byte, error) {
+	var msg bytes.Buffer
+	header := func(k, v string) { msg.WriteString(k + ": " + v + "\r\n") }
+	header("From", from.String())
+	header("To", to.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	msg.WriteString("\r\n")
+
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 {
+		domain = from[i+1:]
+	}
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// deliver 连接 SMTP 服务器并投递一封邮件
+func deliver(ctx context.Context, cfg config.SMTPConfig, from, to string, msg []byte) error {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLS == "tls" {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if cfg.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return i18n.NewError(i18n.MailNoSTARTTLS)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if cfg.Username != "" {
+		// PlainAuth 只在加密连接（或本机服务器）上发送密码
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,210 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"pic/config"
+	"pic/i18n"
+	"strconv"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	templates["t_render"] = template.Must(template.New("t_render").Parse(
+		`{{define "subject"}}  你好
+{{.Name}}  {{end}}{{define "body"}}
+  Hi {{.Name}}
+
+{{end}}`))
+	t.Cleanup(func() { delete(templates, "t_render") })
+
+	// 数据中的换行不能在标题里变成新的邮件头
+	subject, body, err := render("t_render", map[string]string{"Name": "Bob\r\nBcc: evil@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "你好 Bob Bcc: evil@example.com"; subject != want {
+		t.Errorf("subject = %q, 期望 %q", subject, want)
+	}
+	if strings.ContainsAny(subject, "\r\n") {
+		t.Errorf("subject 含有换行: %q", subject)
+	}
+	if want := "Hi Bob\r\nBcc: evil@example.com\n"; body != want {
+		t.Errorf("body = %q, 期望 %q", body, want)
+	}
+
+	_, _, err = render("no_such_template", nil)
+	var coded *i18n.CodedError
+	if !errors.As(err, &coded) || coded.Code != i18n.MailTemplateMissing {
+		t.Errorf("缺失模板应返回 %s，实际 %v", i18n.MailTemplateMissing, err)
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	from := &mail.Address{Name: "Pic", Address: "pic@example.com"}
+	to := &mail.Address{Address: "user@example.org"}
+	body := "第一行 = 等号\n" + strings.Repeat("长", 60) + "\n"
+	raw, err := buildMessage(from, to, "测试 subject", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "测试 subject" {
+		t.Errorf("Subject = %q (%v)", subject, err)
+	}
+	for k, want := range map[string]string{
+		"From":                      `"Pic" <pic@example.com>`,
+		"To":                        "<user@example.org>",
+		"MIME-Version":              "1.0",
+		"Content-Type":              "text/plain; charset=utf-8",
+		"Content-Transfer-Encoding": "quoted-printable",
+	} {
+		if got := msg.Header.Get(k); got != want {
+			t.Errorf("%s = %q, 期望 %q", k, got, want)
+		}
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("Date 无法解析: %v", err)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q", id)
+	}
+
+	encoded, err := io.ReadAll(msg.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(encoded), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("quoted-printable 行超过 76 个字符: %q", line)
+		}
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(encoded))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.ReplaceAll(string(decoded), "\r\n", "\n"); got != body {
+		t.Errorf("正文 = %q, 期望 %q", got, body)
+	}
+}
+
+// fakeSMTP 最简单的 SMTP 服务器（不支持 STARTTLS 和认证），记录收到的命令和邮件内容
+type fakeSMTP struct {
+	addr     string
+	commands chan string
+	data     chan string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &fakeSMTP{addr: ln.Addr().String(), commands: make(chan string, 20), data: make(chan string, 1)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		s.serve(conn)
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.commands <- verb
+		switch verb {
+		case "EHLO":
+			reply("250 localhost")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data <- data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *fakeSMTP) config(t *testing.T, tls string) config.SMTPConfig {
+	t.Helper()
+	host, port, _ := net.SplitHostPort(s.addr)
+	p, _ := strconv.Atoi(port)
+	return config.SMTPConfig{Host: host, Port: p, TLS: tls}
+}
+
+func TestDeliver(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s := newFakeSMTP(t)
+	msg := "Subject: hi\r\n\r\nhello\r\n"
+	if err := deliver(ctx, s.config(t, "none"), "pic@example.com", "user@example.org", []byte(msg)); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-s.data; got != msg {
+		t.Errorf("投递内容 = %q, 期望 %q", got, msg)
+	}
+	close(s.commands)
+	var verbs []string
+	for v := range s.commands {
+		verbs = append(verbs, v)
+	}
+	if got, want := strings.Join(verbs, " "), "EHLO MAIL RCPT DATA QUIT"; got != want {
+		t.Errorf("SMTP 命令 = %s, 期望 %s", got, want)
+	}
+}
+
+func TestDeliverRequiresSTARTTLS(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s := newFakeSMTP(t)
+	err := deliver(ctx, s.config(t, "starttls"), "pic@example.com", "user@example.org", []byte("x"))
+	var coded *i18n.CodedError
+	if !errors.As(err, &coded) || coded.Code != i18n.MailNoSTARTTLS {
+		t.Fatalf("服务器不支持 STARTTLS 时应返回 %s，实际 %v", i18n.MailNoSTARTTLS, err)
+	}
+}
@@ -3,119 +3,491 @@ package main
 import (
 	"context"
 	"errors"
-	"log"
+	"flag"
+	"io/fs"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"pic/announcement"
+	"pic/cache"
+	"pic/cluster"
 	"pic/config"
+	"pic/diskusage"
+	"pic/frontend"
 	"pic/handlers"
+	"pic/i18n"
+	"pic/jobs"
+	"pic/logger"
+	"pic/mailer"
+	"pic/maintenance"
+	"pic/metrics"
 	"pic/middleware"
+	"pic/ratelimit"
+	"pic/reporting"
+	"pic/settings"
+	"pic/tracing"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
-	// 初始化配置
+	// 命令行参数优先于配置文件和环境变量
+	configFile := flag.String("config", os.Getenv("CONFIG_FILE"), "配置文件路径（默认 ./config.yaml）")
+	flag.String("listen", "", "监听地址，如 :8080、127.0.0.1:9090")
+	flag.String("data-dir", "", "数据目录，数据库、图片等相对路径都在该目录下")
+	flag.String("frontend-dir", "", "前端构建产物目录，覆盖内嵌的前端")
+	flag.Parse()
+
+	flagEnv := map[string]string{"listen": "LISTEN_ADDR", "data-dir": "DATA_DIR", "frontend-dir": "FRONTEND_DIR"}
+	overrides := map[string]string{}
+	flag.Visit(func(f *flag.Flag) {
+		if name, ok := flagEnv[f.Name]; ok {
+			overrides[name] = f.Value.String()
+		}
+	})
+
+	// 加载服务器配置（配置文件 + 环境变量 + 命令行参数）
+	cfg, err := config.LoadServerConfig(*configFile, overrides)
+	if err != nil {
+		slog.Error("配置错误", "error", err)
+		os.Exit(1)
+	}
+
+	// 前端资源需在切换工作目录之前打开
+	assets, err := frontend.Open(cfg.Server.FrontendDir)
+	if err != nil {
+		slog.Error("打开前端目录失败", "error", err)
+		os.Exit(1)
+	}
+
+	// 上传等临时文件写入数据目录下的 pic-tmp/，定时清理只处理这个目录，不碰主机上其它进程的临时文件
+	tmpDir, err := maintenance.PrepareTempDir(cfg.Server.DataDir)
+	if err != nil {
+		slog.Error("创建临时目录失败", "error", err)
+		os.Exit(1)
+	}
+	if err := os.Setenv("TMPDIR", tmpDir); err != nil {
+		slog.Error("设置临时目录失败", "path", tmpDir, "error", err)
+		os.Exit(1)
+	}
+
+	// 切换到数据目录，使数据库文件、images/ 等相对路径都落在其中
+	if dir := cfg.Server.DataDir; dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			slog.Error("创建数据目录失败", "data_dir", dir, "error", err)
+			os.Exit(1)
+		}
+		if err := os.Chdir(dir); err != nil {
+			slog.Error("切换到数据目录失败", "data_dir", dir, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// 初始化日志与配置
+	logger.Init(cfg.Log.Level, cfg.Log.Format)
+	if err := reporting.Init(cfg.ErrorReporting.SentryDSN, cfg.ErrorReporting.SentryEnvironment, cfg.ErrorReporting.WebhookURL); err != nil {
+		slog.Error("错误上报初始化失败", "error", err)
+		os.Exit(1)
+	}
 	config.InitDB()
+	config.InitRedis()
+	gitHubTransport, err := config.NewGitHubTransport(observeGitHub)
+	if err != nil {
+		slog.Error("GitHub 网络配置错误", "error", err)
+		os.Exit(1)
+	}
+
+	// 初始化链路追踪
+	shutdownTracing, err := tracing.Init(context.Background(), config.DB)
+	if err != nil {
+		slog.Error("链路追踪初始化失败", "error", err)
+		os.Exit(1)
+	}
+	handlers.SetGitHubTransport(tracing.Transport(gitHubTransport))
+
+	// 后台任务随服务器关闭一起退出
+	appCtx, stopApp := context.WithCancel(context.Background())
+	defer stopApp()
+
+	// 多实例协调（分布式锁、实例间广播），未配置 Redis 时为进程内实现
+	cluster.Init(appCtx, config.Redis)
+
+	// 响应缓存：配置了 Redis 时多实例共享，否则使用进程内缓存
+	if config.Redis != nil {
+		cache.Default = cache.NewRedisStore(config.Redis)
+	} else {
+		cache.Default = cache.NewMemoryStore(appCtx, 10000)
+	}
+
+	// 限流：同样优先使用 Redis 共享计数
+	rateLimits, err := middleware.NewRateLimitConfig(cfg.RateLimit)
+	if err != nil {
+		slog.Error("限流配置错误", "error", err)
+		os.Exit(1)
+	}
+	middleware.SetRateLimits(rateLimits)
+	if config.Redis != nil {
+		middleware.SetRateLimiter(ratelimit.NewRedisLimiter(config.Redis))
+	} else {
+		middleware.SetRateLimiter(ratelimit.NewMemoryLimiter(appCtx))
+	}
+	authLimit := middleware.RateLimit("auth", middleware.ClientIPKey)
+
+	// 加载系统设置
+	if err := settings.Init(appCtx, config.DB); err != nil {
+		slog.Error("系统设置加载失败", "error", err)
+		os.Exit(1)
+	}
+	if err := announcement.Init(config.DB); err != nil {
+		slog.Error("公告表初始化失败", "error", err)
+		os.Exit(1)
+	}
+	if err := jobs.Init(config.DB); err != nil {
+		slog.Error("任务表初始化失败", "error", err)
+		os.Exit(1)
+	}
+	// 各模块在此之前通过 jobs.Register 注册任务类型
+	jobs.Register(mailer.JobType, mailer.HandleJob)
+	jobs.Register(maintenance.JobType, maintenance.HandleJob)
+	waitJobs := jobs.Start(appCtx, cfg.Jobs.Workers, cfg.Jobs.PollInterval.Duration)
+
+	// 本地存储磁盘空间监控
+	if dir := cfg.Storage.LocalDir; dir != "" {
+		if err := diskusage.Start(appCtx, dir, 30*time.Second); err != nil {
+			slog.Warn("⚠️ 无法获取本地存储目录的磁盘空间，不做监控", "path", dir, "error", err)
+		}
+	}
+
+	// 定时清理。过期分享链接、会话和后端文件已丢失的图片记录在本服务中还没有对应的表，暂不清理
+	maintenance.Register(maintenance.TempFiles(tmpDir, cfg.Maintenance.TempFileMaxAge.Duration))
+	maintenance.Register(maintenance.FinishedJobs(cfg.Maintenance.JobRetention.Duration))
+	maintenance.Start(appCtx, cfg.Maintenance.Interval.Duration, cfg.Maintenance.DryRun)
+
+	// 配置热加载（SIGHUP 或 POST /api/admin/reload）：跨域、限流、访问控制、日志级别和系统设置，
+	// 只替换新请求使用的配置，进行中的请求（包括上传）不受影响
+	config.OnReload(func(cfg *config.ServerConfig) {
+		logger.SetLevel(cfg.Log.Level)
+		if limits, err := middleware.NewRateLimitConfig(cfg.RateLimit); err == nil {
+			middleware.SetRateLimits(limits)
+		}
+		if err := settings.Reload(); err != nil {
+			slog.Error("重新加载系统设置失败", "error", err)
+		}
+	})
 
 	// 创建Gin路由
-	r := gin.Default()
+	r := gin.New()
 
-	// 允许跨域
-	r.Use(middleware.CORS())
+	// 真实客户端 IP：只信任配置的代理
+	if err := r.SetTrustedProxies(cfg.Proxy.TrustedProxies); err != nil {
+		slog.Error("可信代理配置错误", "error", err)
+		os.Exit(1)
+	}
+	r.RemoteIPHeaders = cfg.Proxy.ClientIPHeaders
+	r.TrustedPlatform = cfg.Proxy.PlatformHeader()
+
+	// 请求ID、访问日志、指标、链路追踪与 panic 恢复
+	accessLog, accessLogFile, err := middleware.AccessLog(cfg.AccessLog)
+	if err != nil {
+		slog.Error("访问日志初始化失败", "error", err)
+		os.Exit(1)
+	}
+	r.Use(middleware.RequestID())
+	r.Use(accessLog)
+	r.Use(middleware.Metrics())
+	if tracing.Enabled() {
+		r.Use(otelgin.Middleware(tracing.ServiceName))
+	}
+	r.Use(middleware.Recovery())
+
+	// 请求体大小限制：JSON 接口默认 1MB，上传接口由 UploadPolicy 按系统设置限制
+	r.Use(middleware.BodyLimit(cfg.Server.BodyLimitKB<<10, "/api/upload"))
+
+	// 响应压缩
+	r.Use(middleware.Compress())
+
+	// 跨域策略
+	r.Use(middleware.CORSFromConfig())
+
+	// 维护模式与只读模式
+	r.Use(middleware.MaintenanceMode())
+	r.Use(middleware.ReadOnlyMode())
+
+	// 健康检查
+	r.GET("/healthz", handlers.Healthz)
+	r.GET("/readyz", handlers.Readyz)
+
+	// Prometheus 指标（Bearer Token 或 IP 白名单）
+	if config.DB != nil {
+		if sqlDB, err := config.DB.DB(); err == nil {
+			metrics.RegisterDB(sqlDB)
+		}
+	}
+	r.GET("/metrics",
+		middleware.MetricsAuth(),
+		gin.WrapH(metrics.Handler()))
 
 	// 公开路由
 	public := r.Group("/api")
 	{
-		public.POST("/auth/login", handlers.Login)
-		public.POST("/auth/register", handlers.Register)
+		public.POST("/auth/login", authLimit, handlers.Login)
+		public.POST("/auth/register", authLimit, middleware.RegistrationOpen(), handlers.Register)
 	}
 
 	// 公开路由（无需认证）
-	r.GET("/api/gallery/:slug", handlers.GetPublicGallery)
+	r.GET("/api/gallery/:slug",
+		middleware.RateLimit("gallery", middleware.ClientIPKey),
+		middleware.PublicGalleryEnabled(),
+		middleware.ConditionalGET(func(cfg *config.ServerConfig) string { return cfg.HTTPCache.Gallery }),
+		middleware.LastModified("gallery", func(c *gin.Context) string { return c.Param("slug") }),
+		middleware.CacheResponse("gallery", time.Minute, func(c *gin.Context) string { return c.Param("slug") }),
+		handlers.GetPublicGallery)
+	r.GET("/api/announcements", handlers.GetPublicAnnouncements)
+
+	// API文档
+	r.GET("/api/docs", handlers.SwaggerUI)
+	r.GET("/api/docs/openapi.json", handlers.OpenAPISpec(r))
+	r.GET("/api/docs/assets/:file", handlers.SwaggerAsset)
 
 	// 需要认证的路由
 	protected := r.Group("/api")
 	protected.Use(middleware.AuthMiddleware())
+	// 额度查询在 api 限流之前注册，查询本身不消耗额度
+	protected.GET("/rate-limit", handlers.GetRateLimit)
+	protected.Use(middleware.RateLimit("api", middleware.CredentialOrIPKey))
+	// 关闭公告不影响图库和图片列表，在写操作失效缓存之前注册
+	protected.POST("/announcements/:id/dismiss", handlers.DismissAnnouncement)
+	// 任何成功的写操作都可能改变公开图库和当前用户的图片列表；
+	// 图库的修改水位由处理器通过 middleware.MarkModified 标记 slug，未标记时更新所有图库
+	protected.Use(middleware.ModifiesResource("images", middleware.UserOrSessionKey), middleware.InvalidateCache("gallery", "images"))
+	configCache := middleware.CacheResponse("config", 5*time.Minute, middleware.SessionCacheKey)
 	{
 		// GitHub相关
 		protected.GET("/github/repos", handlers.GetRepositories)
+		protected.POST("/github/repos", handlers.CreateRepository)
 		protected.POST("/github/verify-token", handlers.VerifyGitHubToken)
 
 		// 配置管理
-		protected.POST("/config", handlers.SaveConfig)
-		protected.GET("/config", handlers.GetConfig)
+		protected.POST("/config", middleware.InvalidateCache("config"), handlers.SaveConfig)
+		protected.GET("/config", configCache, handlers.GetConfig)
 		protected.GET("/gallery/check-slug", handlers.CheckGallerySlug)
 
 		// 图片上传
-		protected.POST("/upload", handlers.UploadImage)
-		protected.GET("/images", handlers.GetImages)
+		protected.POST("/upload",
+			middleware.RateLimit("upload", middleware.UserOrIPKey),
+			middleware.DiskSpaceGuard(),
+			middleware.UploadPolicy(),
+			handlers.UploadImage)
+		protected.GET("/images",
+			middleware.ConditionalGET(func(cfg *config.ServerConfig) string { return cfg.HTTPCache.Images }),
+			middleware.LastModified("images", middleware.UserOrSessionKey),
+			handlers.GetImages)
 		protected.DELETE("/images/:id", handlers.DeleteImage)
+		protected.GET("/qrcode", handlers.GetQRCode)
+
+		// 公告
+		protected.GET("/announcements/current", handlers.GetUserAnnouncements)
 	}
 
-	// 静态文件服务（前端）
-	r.Static("/assets", "./frontend/dist/assets")
-	r.StaticFile("/favicon.svg", "./frontend/dist/favicon.svg")
+	// 管理接口
+	if cfg.Admin.Token == "" && len(cfg.Admin.AllowedIPs) == 0 {
+		slog.Warn("⚠️ 未配置 admin.token 或 admin.allowed_ips，管理接口将拒绝所有访问")
+	}
+	admin := r.Group("/api/admin")
+	admin.Use(middleware.AdminAuth())
+	{
+		handlers.MountDebug(admin)
+		admin.POST("/reload", handlers.ReloadConfig)
+
+		// 系统设置
+		admin.GET("/settings", handlers.GetSystemSettings)
+		admin.PUT("/settings", handlers.UpdateSystemSettings)
+
+		// 公告管理
+		admin.GET("/announcements", handlers.ListAnnouncements)
+		admin.POST("/announcements", handlers.CreateAnnouncement)
+		admin.PUT("/announcements/:id", handlers.UpdateAnnouncement)
+		admin.DELETE("/announcements/:id", handlers.DeleteAnnouncement)
+
+		// 后台任务
+		admin.GET("/jobs", handlers.ListJobs)
+		admin.GET("/jobs/:id", handlers.GetJob)
+		admin.POST("/jobs/:id/retry", handlers.RetryJob)
+		admin.DELETE("/jobs/:id", handlers.DeleteJob)
+
+		// 存储
+		admin.GET("/storage/disk", handlers.GetDiskUsage)
+
+		// 邮件
+		admin.POST("/mail/test", handlers.SendTestMail)
+
+		// 清理
+		admin.POST("/maintenance/run", handlers.RunMaintenance)
+		admin.GET("/maintenance/report", handlers.GetMaintenanceReport)
+	}
+
+	// 静态文件服务（前端）：内嵌资源或磁盘目录
+	if _, err := fs.Stat(assets, "index.html"); err != nil {
+		slog.Warn("前端资源中没有 index.html，仅提供 API", "frontend_dir", cfg.Server.FrontendDir, "embedded", frontend.Embedded())
+	}
+	if assetsDir, err := fs.Sub(assets, "assets"); err == nil {
+		r.StaticFS("/assets", http.FS(assetsDir))
+	}
+	r.StaticFileFS("/favicon.svg", "favicon.svg", http.FS(assets))
 
 	// SPA路由支持：所有非API请求都返回index.html
 	r.NoRoute(func(c *gin.Context) {
 		path := c.Request.URL.Path
 		// 排除API请求
 		if strings.HasPrefix(path, "/api") {
-			c.JSON(404, gin.H{"error": "API路由不存在"})
-		} else {
-			c.File("./frontend/dist/index.html")
+			c.JSON(404, i18n.Error(c, i18n.APINotFound))
+			return
 		}
+		index, err := fs.ReadFile(assets, "index.html")
+		if err != nil {
+			c.JSON(404, i18n.Error(c, i18n.FrontendNotFound))
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
 	})
 
-	log.Println("🚀 服务器启动在 http://localhost:9090")
-
 	// 创建HTTP服务器
 	srv := &http.Server{
-		Addr:           ":9090",
+		Addr:           cfg.Server.Listen,
 		Handler:        r,
-		ReadTimeout:    15 * time.Second,
-		WriteTimeout:   60 * time.Second, // 增加到60秒以支持大文件上传
-		MaxHeaderBytes: 1 << 20,          // 1MB
+		ReadTimeout:    cfg.Server.ReadTimeout.Duration,
+		WriteTimeout:   cfg.Server.WriteTimeout.Duration,
+		MaxHeaderBytes: 1 << 20, // 1MB
+	}
+	redirectSrv := setupTLS(srv, cfg.TLS)
+
+	slog.Info("🚀 服务器启动", "addr", cfg.Server.Listen, "tls", cfg.TLS.Enabled())
+
+	ln, err := listen(cfg.Server.Listen)
+	if err != nil {
+		slog.Error("监听失败", "addr", cfg.Server.Listen, "error", err)
+		os.Exit(1)
+	}
+	if ln.Addr().Network() == "unix" {
+		srv.Handler = unixRemoteAddr(srv.Handler)
 	}
 
 	// 在goroutine中启动服务器
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal("服务器启动失败:", err)
+		var err error
+		if cfg.TLS.Enabled() {
+			// autocert 模式下证书来自 TLSConfig，文件参数为空
+			err = srv.ServeTLS(ln, cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		} else {
+			err = srv.Serve(ln)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("服务器启动失败", "error", err)
+			os.Exit(1)
 		}
 	}()
+	if redirectSrv != nil {
+		go func() {
+			slog.Info("↪️ HTTP 跳转 HTTPS", "addr", redirectSrv.Addr)
+			if err := redirectSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("HTTP 跳转服务启动失败", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	// SIGHUP 重新加载配置
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	reloadConfig := func() {
+		if _, err := config.ReloadServerConfig(); err != nil {
+			slog.Error("重新加载配置失败，继续使用原配置", "error", err)
+		}
+	}
+	go func() {
+		for range hup {
+			reloadConfig()
+		}
+	}()
+	// 其它实例收到 POST /api/admin/reload 时一并重新加载
+	cluster.Subscribe("config-reload", func(string) { reloadConfig() })
 
 	// 等待中断信号以优雅地关闭服务器
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	<-quit
-	log.Println("🔄 正在关闭服务器...")
+	slog.Info("🔄 正在关闭服务器...")
+	stopApp()
 
-	// 设置30秒超时的context用于优雅关闭
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// 设置超时的context用于优雅关闭
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Duration)
 	defer cancel()
 
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("服务器关闭超时或出错: %v", err)
+		slog.Error("服务器关闭超时或出错", "error", err)
+	}
+	if redirectSrv != nil {
+		if err := redirectSrv.Shutdown(ctx); err != nil {
+			slog.Error("HTTP 跳转服务器关闭超时或出错", "error", err)
+		}
+	}
+
+	// 等待正在执行的后台任务结束
+	waitJobs()
+
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("刷新链路追踪数据失败", "error", err)
+	}
+
+	reporting.Flush(5 * time.Second)
+
+	if err := accessLogFile.Close(); err != nil {
+		slog.Error("关闭访问日志失败", "error", err)
 	}
 
 	// 关闭数据库连接
 	if config.DB != nil {
 		sqlDB, err := config.DB.DB()
 		if err != nil {
-			log.Printf("获取底层数据库连接失败: %v", err)
+			slog.Error("获取底层数据库连接失败", "error", err)
 		} else {
 			if err := sqlDB.Close(); err != nil {
-				log.Printf("关闭数据库连接失败: %v", err)
+				slog.Error("关闭数据库连接失败", "error", err)
 			} else {
-				log.Println("✅ 数据库连接已关闭")
+				slog.Info("✅ 数据库连接已关闭")
 			}
 		}
 	}
 
-	log.Println("✅ 服务器已优雅退出")
+	if config.Redis != nil {
+		if err := config.Redis.Close(); err != nil {
+			slog.Error("关闭 Redis 连接失败", "error", err)
+		}
+	}
+
+	slog.Info("✅ 服务器已优雅退出")
+}
+
+// observeGitHub 记录 GitHub 请求失败和 5xx/429 的指标与日志，并更新剩余调用额度
+func observeGitHub(req *http.Request, resp *http.Response, err error) {
+	if err != nil {
+		metrics.BackendErrorsTotal.WithLabelValues("github").Inc()
+		logger.FromContext(req.Context()).Warn("GitHub 请求失败",
+			"method", req.Method, "url", req.URL.Redacted(), "error", err)
+		return
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		metrics.GitHubRateLimitRemaining.Set(float64(remaining))
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		metrics.BackendErrorsTotal.WithLabelValues("github").Inc()
+		logger.FromContext(req.Context()).Warn("GitHub 返回错误状态",
+			"method", req.Method, "url", req.URL.Redacted(), "status", resp.StatusCode)
+	}
 }
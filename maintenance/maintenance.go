@@ -0,0 +1,186 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"pic/cluster"
+	"pic/jobs"
+	"sync"
+	"time"
+)
+
+// Task 一项清理任务
+type Task struct {
+	Name string
+	// PerInstance 为 true 时每个实例都执行（例如本机临时文件），否则多实例中只有一个执行
+	PerInstance bool
+	// Run 执行清理，dryRun 为 true 时只统计不删除
+	Run func(ctx context.Context, dryRun bool) (TaskResult, error)
+}
+
+// TaskResult 单项任务的结果
+type TaskResult struct {
+	Name string `json:"name"`
+	// Matched 符合清理条件的数量，Removed 实际删除的数量（演练时为 0）
+	Matched int `json:"matched"`
+	Removed int `json:"removed"`
+	// Samples 部分被清理的对象，便于演练时核对
+	Samples []string `json:"samples,omitempty"`
+	Skipped bool     `json:"skipped,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Report 一次清理的报告
+type Report struct {
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt time.Time    `json:"finished_at"`
+	DryRun     bool         `json:"dry_run"`
+	Instance   string       `json:"instance"`
+	Tasks      []TaskResult `json:"tasks"`
+}
+
+// maxSamples 报告中每项任务最多列出的对象数
+const maxSamples = 20
+
+var (
+	mu    sync.Mutex
+	tasks []Task
+	last  *Report
+)
+
+// Register 注册清理任务，需在 Start 之前调用
+func Register(t Task) {
+	mu.Lock()
+	defer mu.Unlock()
+	tasks = append(tasks, t)
+}
+
+// Run 依次执行所有清理任务。非 PerInstance 的任务需取得集群锁，其它实例正在执行时跳过。
+func Run(ctx context.Context, dryRun bool) Report {
+	return run(ctx, dryRun, func(Task) bool { return true })
+}
+
+// run 依次执行 match 选中的清理任务并记录报告
+func run(ctx context.Context, dryRun bool, match func(Task) bool) Report {
+	mu.Lock()
+	var list []Task
+	for _, t := range tasks {
+		if match(t) {
+			list = append(list, t)
+		}
+	}
+	mu.Unlock()
+
+	report := Report{StartedAt: time.Now(), DryRun: dryRun, Instance: cluster.InstanceID()}
+	for _, t := range list {
+		report.Tasks = append(report.Tasks, runTask(ctx, t, dryRun))
+	}
+	report.FinishedAt = time.Now()
+
+	mu.Lock()
+	last = &report
+	mu.Unlock()
+	return report
+}
+
+func runTask(ctx context.Context, t Task, dryRun bool) TaskResult {
+	var (
+		res TaskResult
+		err error
+	)
+	if t.PerInstance {
+		res, err = t.Run(ctx, dryRun)
+	} else {
+		var ran bool
+		ran, err = cluster.WithLock(ctx, "maintenance:"+t.Name, 30*time.Minute, func(ctx context.Context) error {
+			var runErr error
+			res, runErr = t.Run(ctx, dryRun)
+			return runErr
+		})
+		if err == nil && !ran {
+			res.Skipped = true
+		}
+	}
+
+	res.Name = t.Name
+	if len(res.Samples) > maxSamples {
+		res.Samples = res.Samples[:maxSamples]
+	}
+	if err != nil {
+		res.Error = err.Error()
+		slog.Error("清理任务失败", "task", t.Name, "error", err)
+	} else if res.Matched > 0 {
+		slog.Info("🧹 清理任务完成", "task", t.Name, "matched", res.Matched, "removed", res.Removed, "dry_run", dryRun)
+	}
+	return res
+}
+
+// Last 最近一次清理的报告，尚未执行过时为 nil
+func Last() *Report {
+	mu.Lock()
+	defer mu.Unlock()
+	return last
+}
+
+// JobType 定时清理中多实例只需执行一次的任务（非 PerInstance），放入后台任务队列由任一实例的 worker 执行，
+// 失败时按队列的退避时间重试。需通过 jobs.Register 注册 HandleJob。
+const JobType = "maintenance.cleanup"
+
+type jobPayload struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// HandleJob 执行 JobType 任务：依次执行非 PerInstance 的清理任务，任一任务失败时返回错误
+func HandleJob(ctx context.Context, job *jobs.Job) error {
+	var p jobPayload
+	if err := job.Decode(&p); err != nil {
+		return fmt.Errorf("清理任务参数错误: %w", err)
+	}
+	report := run(ctx, p.DryRun, func(t Task) bool { return !t.PerInstance })
+	var errs []error
+	for _, res := range report.Tasks {
+		if res.Error != "" {
+			errs = append(errs, fmt.Errorf("%s: %s", res.Name, res.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Start 每隔 interval 执行一次清理，interval 为 0 时不定时执行（仍可通过管理接口手动执行）。
+// PerInstance 任务在本实例直接执行，其余任务每个周期只由一个实例放入后台任务队列。
+func Start(ctx context.Context, interval time.Duration, dryRun bool) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run(ctx, dryRun, func(t Task) bool { return t.PerInstance })
+				schedule(ctx, interval, dryRun)
+			}
+		}
+	}()
+}
+
+// schedule 将本周期的 JobType 任务入队。锁不主动释放，在略短于一个周期后过期，
+// 各实例的定时器不同步时每个周期也只入队一次
+func schedule(ctx context.Context, interval time.Duration, dryRun bool) {
+	_, ok, err := cluster.TryLock(ctx, "maintenance:schedule", interval*9/10)
+	if err != nil {
+		slog.Warn("安排定时清理失败", "error", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	if _, err := jobs.Enqueue(ctx, JobType, jobPayload{DryRun: dryRun}); err != nil {
+		slog.Warn("安排定时清理失败", "error", err)
+	}
+}
@@ -0,0 +1,41 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"pic/jobs"
+	"strings"
+	"testing"
+)
+
+func TestHandleJob(t *testing.T) {
+	defer func(old []Task) { tasks = old }(tasks)
+
+	var ran []string
+	task := func(name string, perInstance bool, err error) Task {
+		return Task{Name: name, PerInstance: perInstance, Run: func(ctx context.Context, dryRun bool) (TaskResult, error) {
+			if !dryRun {
+				t.Errorf("%s: dryRun 未传递", name)
+			}
+			ran = append(ran, name)
+			return TaskResult{}, err
+		}}
+	}
+	tasks = []Task{
+		task("temp_files", true, nil),
+		task("finished_jobs", false, nil),
+		task("broken", false, errors.New("boom")),
+	}
+
+	err := HandleJob(context.Background(), &jobs.Job{Type: JobType, Payload: `{"dry_run":true}`})
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("err = %v, 期望包含失败的任务", err)
+	}
+	// PerInstance 任务由各实例自己的定时器执行，不能放进队列只在一个实例上执行
+	if strings.Join(ran, ",") != "finished_jobs,broken" {
+		t.Fatalf("执行的任务 = %v", ran)
+	}
+	if r := Last(); r == nil || len(r.Tasks) != 2 || !r.DryRun {
+		t.Fatalf("报告 = %+v", r)
+	}
+}
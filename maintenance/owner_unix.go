@@ -0,0 +1,13 @@
+//go:build linux || darwin || freebsd
+
+package maintenance
+
+import (
+	"os"
+	"syscall"
+)
+
+func ownedBySelf(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
@@ -0,0 +1,64 @@
+package maintenance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"pic/jobs"
+	"time"
+)
+
+// TempFiles 清理本服务临时目录 dir 中超过 maxAge 的文件（multipart 解析时写入磁盘的上传文件等）。
+// 正常情况下请求结束时会删除，进程崩溃或被杀时会残留。dir 只供本服务使用（见 PrepareTempDir 和 main 中设置的 TMPDIR），
+// 不清理系统临时目录，以免删除主机上其它进程的文件。
+func TempFiles(dir string, maxAge time.Duration) Task {
+	return Task{
+		Name:        "temp_files",
+		PerInstance: true,
+		Run: func(ctx context.Context, dryRun bool) (TaskResult, error) {
+			var res TaskResult
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				return res, err
+			}
+			cutoff := time.Now().Add(-maxAge)
+			for _, e := range entries {
+				if !e.Type().IsRegular() {
+					continue
+				}
+				info, err := e.Info()
+				if err != nil || info.ModTime().After(cutoff) {
+					continue
+				}
+				path := filepath.Join(dir, e.Name())
+				res.Matched++
+				if len(res.Samples) < maxSamples {
+					res.Samples = append(res.Samples, path)
+				}
+				if dryRun {
+					continue
+				}
+				if err := os.Remove(path); err == nil {
+					res.Removed++
+				}
+			}
+			return res, ctx.Err()
+		},
+	}
+}
+
+// FinishedJobs 删除完成超过 retention 的后台任务记录，失败的任务保留给管理员处理
+func FinishedJobs(retention time.Duration) Task {
+	return Task{
+		Name: "finished_jobs",
+		Run: func(ctx context.Context, dryRun bool) (TaskResult, error) {
+			var res TaskResult
+			n, err := jobs.PurgeFinished(ctx, time.Now().Add(-retention), dryRun)
+			res.Matched = int(n)
+			if !dryRun {
+				res.Removed = int(n)
+			}
+			return res, err
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTempFiles(t *testing.T) {
+	dir, other := t.TempDir(), t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+	touch := func(path string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	touch(filepath.Join(dir, "multipart-1"), old)
+	touch(filepath.Join(dir, "multipart-2"), time.Now())
+	// 其它目录中的同名临时文件属于其它进程，不能被删除
+	touch(filepath.Join(other, "multipart-3"), old)
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	task := TempFiles(dir, time.Hour)
+	for _, tc := range []struct {
+		name    string
+		dryRun  bool
+		removed int
+	}{
+		{"dry run", true, 0},
+		{"remove", false, 1},
+	} {
+		res, err := task.Run(context.Background(), tc.dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Matched != 1 || res.Removed != tc.removed {
+			t.Fatalf("%s: %+v", tc.name, res)
+		}
+	}
+	for path, exists := range map[string]bool{
+		filepath.Join(dir, "multipart-1"):   false,
+		filepath.Join(dir, "multipart-2"):   true,
+		filepath.Join(other, "multipart-3"): true,
+		filepath.Join(dir, "sub"):           true,
+	} {
+		if _, err := os.Stat(path); (err == nil) != exists {
+			t.Errorf("%s 存在 = %v, 期望 %v", path, err == nil, exists)
+		}
+	}
+}
+
+func TestTempFilesSamples(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-2 * time.Hour)
+	for i := 0; i < maxSamples+5; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("multipart-%d", i))
+		if err := os.WriteFile(path, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := TempFiles(dir, time.Hour).Run(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Matched != maxSamples+5 || len(res.Samples) != maxSamples {
+		t.Fatalf("matched = %d, samples = %d", res.Matched, len(res.Samples))
+	}
+}
+
+func TestPrepareTempDir(t *testing.T) {
+	base := t.TempDir()
+	// 测试临时目录可能经过符号链接，按工作目录解析后的路径比较
+	t.Chdir(base)
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		dataDir string
+		want    string
+	}{
+		{"", filepath.Join(cwd, "pic-tmp")},
+		{"data", filepath.Join(cwd, "data", "pic-tmp")},
+		{filepath.Join(cwd, "abs"), filepath.Join(cwd, "abs", "pic-tmp")},
+	} {
+		dir, err := PrepareTempDir(tc.dataDir)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.dataDir, err)
+		}
+		if dir != tc.want {
+			t.Errorf("%q: 得到 %s, 期望 %s", tc.dataDir, dir, tc.want)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Errorf("%q: 目录未创建: %v", tc.dataDir, err)
+		}
+	}
+
+	// 解析结果与系统临时目录相同时拒绝，以免清理任务删除其它进程的文件
+	t.Setenv("TMPDIR", filepath.Join(cwd, "sys", "pic-tmp"))
+	if _, err := PrepareTempDir("sys"); err == nil {
+		t.Fatal("与系统临时目录相同的路径应当报错")
+	}
+	if _, err := os.Stat(filepath.Join(cwd, "sys")); !os.IsNotExist(err) {
+		t.Errorf("拒绝时不应创建目录: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package maintenance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// tempDirName 数据目录下本服务专用的临时目录
+const tempDirName = "pic-tmp"
+
+// PrepareTempDir 创建并返回 dataDir 下本服务专用的临时目录（dataDir 为空时为工作目录），
+// 需在切换到数据目录之前调用。TempFiles 会删除其中的旧文件，因此目录不能是系统临时目录，
+// 也必须属于当前进程的用户。
+func PrepareTempDir(dataDir string) (string, error) {
+	dir, err := filepath.Abs(filepath.Join(dataDir, tempDirName))
+	if err != nil {
+		return "", err
+	}
+	if sys, err := filepath.Abs(os.TempDir()); err == nil && sys == dir {
+		return "", fmt.Errorf("临时目录 %s 与系统临时目录相同", dir)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("临时目录 %s 不是目录", dir)
+	}
+	if !ownedBySelf(info) {
+		return "", fmt.Errorf("临时目录 %s 不属于当前用户", dir)
+	}
+	return dir, nil
+}
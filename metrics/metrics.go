@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 服务端指标，统一使用 pic_ 前缀
+var (
+	RequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pic_http_request_duration_seconds",
+		Help:    "HTTP 请求耗时（秒）",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"method", "route", "status"})
+
+	UploadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pic_uploads_total",
+		Help: "上传请求次数",
+	}, []string{"result"})
+
+	UploadBytesTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pic_upload_bytes_total",
+		Help: "成功上传的请求体字节数",
+	})
+
+	BackendErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pic_backend_errors_total",
+		Help: "存储后端请求失败次数",
+	}, []string{"backend"})
+
+	GitHubRateLimitRemaining = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "pic_github_rate_limit_remaining",
+		Help: "最近一次 GitHub API 响应中的剩余请求额度",
+	})
+
+	DiskFreeBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pic_disk_free_bytes",
+		Help: "本地存储目录所在磁盘的可用空间（字节）",
+	}, []string{"path"})
+
+	DiskTotalBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pic_disk_total_bytes",
+		Help: "本地存储目录所在磁盘的总空间（字节）",
+	}, []string{"path"})
+
+	JobsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "pic_jobs_total",
+		Help: "后台任务执行次数（result 为 done/retry/failed）",
+	}, []string{"type", "result"})
+)
+
+var registry = prometheus.NewRegistry()
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RequestDuration,
+		UploadsTotal,
+		UploadBytesTotal,
+		BackendErrorsTotal,
+		GitHubRateLimitRemaining,
+		DiskFreeBytes,
+		DiskTotalBytes,
+		JobsTotal,
+	)
+}
+
+// RegisterDB 注册数据库连接池指标
+func RegisterDB(db *sql.DB) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, "pic"))
+}
+
+// Handler 返回 Prometheus 文本格式的指标输出
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
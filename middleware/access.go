@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"pic/config"
+	"pic/i18n"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RestrictAccess 限制运维类接口的访问：携带正确的 Bearer Token，或直连的对端 IP 位于白名单内。
+// 白名单匹配 c.RemoteIP() 而不是 c.ClientIP()，代理头无法伪造来源；经反向代理访问时对端是代理本身，
+// 把代理地址加入白名单等于放行所有经过该代理的请求，这种部署应使用 token。
+// unix socket 连接没有真实的对端 IP，只接受 token。token 和 allowlist 都为空时拒绝所有访问。
+func RestrictAccess(token string, allowlist []string) gin.HandlerFunc {
+	nets := parseIPNets(allowlist)
+
+	return func(c *gin.Context) {
+		if token != "" && bearerTokenEquals(c, token) {
+			c.Next()
+			return
+		}
+
+		if !fromUnixSocket(c.Request) {
+			if ip := net.ParseIP(c.RemoteIP()); ip != nil {
+				for _, ipNet := range nets {
+					if ipNet.Contains(ip) {
+						c.Next()
+						return
+					}
+				}
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, i18n.Error(c, i18n.Forbidden))
+	}
+}
+
+type unixSocketKey struct{}
+
+// MarkUnixSocket 标记请求来自 unix socket 监听，RestrictAccess 对这类请求不按 IP 白名单放行
+func MarkUnixSocket(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), unixSocketKey{}, true))
+}
+
+func fromUnixSocket(r *http.Request) bool {
+	unix, _ := r.Context().Value(unixSocketKey{}).(bool)
+	return unix
+}
+
+// bearerTokenEquals 以常量时间比较 Authorization 头中的 Bearer Token
+func bearerTokenEquals(c *gin.Context, token string) bool {
+	auth := c.GetHeader("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) == 1
+}
+
+// parseIPNets 解析 IP/CIDR 列表，单个 IP 视为 /32 或 /128，无法解析的条目忽略
+func parseIPNets(entries []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			nets = append(nets, ipNet)
+		}
+	}
+	return nets
+}
+
+// AdminAuth 保护管理接口：admin.token 作为 Bearer Token，或直连 IP 位于 admin.allowed_ips 中，都未配置时管理接口不可用
+func AdminAuth() gin.HandlerFunc {
+	return Reloadable(func(cfg *config.ServerConfig) gin.HandlerFunc {
+		return RestrictAccess(cfg.Admin.Token, cfg.Admin.AllowedIPs)
+	})
+}
+
+// MetricsAuth 保护 /metrics：metrics.token 作为 Bearer Token，或直连 IP 位于 metrics.allowed_ips 中，都未配置时 /metrics 不可用
+func MetricsAuth() gin.HandlerFunc {
+	return Reloadable(func(cfg *config.ServerConfig) gin.HandlerFunc {
+		return RestrictAccess(cfg.Metrics.Token, cfg.Metrics.AllowedIPs)
+	})
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"pic/config"
+	"pic/logger"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AccessLog 根据配置创建访问日志中间件，返回的 io.Closer 用于退出时关闭日志文件
+func AccessLog(cfg config.AccessLogConfig) (gin.HandlerFunc, io.Closer, error) {
+	var (
+		out    io.Writer
+		closer io.Closer = nopCloser{}
+	)
+	switch cfg.Output {
+	case "off", "none":
+		return func(c *gin.Context) { c.Next() }, closer, nil
+	case "", "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	default:
+		var interval time.Duration
+		switch cfg.Rotate {
+		case "hourly":
+			interval = time.Hour
+		case "daily":
+			interval = 24 * time.Hour
+		case "":
+		default:
+			return nil, nil, fmt.Errorf("access_log.rotate 只支持 hourly/daily: %q", cfg.Rotate)
+		}
+		rf, err := logger.OpenRotatingFile(cfg.Output, int64(cfg.MaxSizeMB)<<20, interval, cfg.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closer = rf, rf
+	}
+
+	var format func(*gin.Context, time.Time, time.Duration) []byte
+	switch cfg.Format {
+	case "", "json":
+		format = formatAccessJSON
+	case "combined":
+		format = formatAccessCombined
+	default:
+		return nil, nil, fmt.Errorf("access_log.format 只支持 json/combined: %q", cfg.Format)
+	}
+
+	exclude := cfg.Exclude
+	var mu sync.Mutex
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		path := c.Request.URL.Path
+		for _, prefix := range exclude {
+			if strings.HasPrefix(path, prefix) {
+				return
+			}
+		}
+
+		line := format(c, start, time.Since(start))
+		mu.Lock()
+		_, _ = out.Write(line)
+		mu.Unlock()
+	}, closer, nil
+}
+
+func formatAccessJSON(c *gin.Context, start time.Time, latency time.Duration) []byte {
+	entry := map[string]interface{}{
+		"time":       start.Format(time.RFC3339Nano),
+		"request_id": c.GetString(RequestIDKey),
+		"client_ip":  c.ClientIP(),
+		"method":     c.Request.Method,
+		"path":       c.Request.URL.Path,
+		"route":      c.FullPath(),
+		"status":     c.Writer.Status(),
+		"bytes":      c.Writer.Size(),
+		"latency_ms": latency.Milliseconds(),
+		"referer":    c.Request.Referer(),
+		"user_agent": c.Request.UserAgent(),
+	}
+	if query := c.Request.URL.RawQuery; query != "" {
+		entry["query"] = query
+	}
+	line, _ := json.Marshal(entry)
+	return append(line, '\n')
+}
+
+func formatAccessCombined(c *gin.Context, start time.Time, _ time.Duration) []byte {
+	size := "-"
+	if n := c.Writer.Size(); n > 0 {
+		size = strconv.Itoa(n)
+	}
+	return []byte(fmt.Sprintf("%s - - [%s] %q %d %s %q %q\n",
+		c.ClientIP(),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		c.Request.Method+" "+c.Request.URL.RequestURI()+" "+c.Request.Proto,
+		c.Writer.Status(),
+		size,
+		c.Request.Referer(),
+		c.Request.UserAgent(),
+	))
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRestrictAccess(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		token      string
+		allowlist  []string
+		remoteAddr string
+		headers    map[string]string
+		unix       bool
+		want       int
+	}{
+		{"nothing configured, loopback", "", nil, "127.0.0.1:1234", nil, false, http.StatusForbidden},
+		{"nothing configured, token sent", "", nil, "127.0.0.1:1234", map[string]string{"Authorization": "Bearer "}, false, http.StatusForbidden},
+		{"token", "secret", nil, "203.0.113.9:1234", map[string]string{"Authorization": "Bearer secret"}, false, http.StatusOK},
+		{"wrong token", "secret", nil, "203.0.113.9:1234", map[string]string{"Authorization": "Bearer nope"}, false, http.StatusForbidden},
+		{"allowlisted peer", "", []string{"10.0.0.0/8"}, "10.1.2.3:1234", nil, false, http.StatusOK},
+		{"peer not allowlisted", "", []string{"10.0.0.0/8"}, "203.0.113.9:1234", nil, false, http.StatusForbidden},
+		// 本机反向代理转发的外部请求：对端是 127.0.0.1，但客户端 IP 是外部地址，无 token 时不能放行
+		{"proxied external client", "secret", nil, "127.0.0.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.9"}, false, http.StatusForbidden},
+		// 伪造代理头冒充白名单地址
+		{"spoofed forwarded header", "", []string{"127.0.0.1"}, "203.0.113.9:1234", map[string]string{"X-Forwarded-For": "127.0.0.1"}, false, http.StatusForbidden},
+		{"unix socket ignores allowlist", "", []string{"127.0.0.1"}, "127.0.0.1:0", nil, true, http.StatusForbidden},
+		{"unix socket with token", "secret", []string{"127.0.0.1"}, "127.0.0.1:0", map[string]string{"Authorization": "Bearer secret"}, true, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			// 模拟信任本机反向代理的部署，ClientIP 会取自 X-Forwarded-For
+			r.SetTrustedProxies([]string{"127.0.0.1"})
+			r.GET("/admin", RestrictAccess(tc.token, tc.allowlist), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			if tc.unix {
+				req = MarkUnixSocket(req)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tc.want {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+	"pic/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit 限制请求体大小，超出时返回 413。skipRoutes 中的路由（如上传接口）自行设置更大的限制。
+// Content-Length 超限时直接拒绝；未声明长度的请求由 http.MaxBytesReader 在读取时截断。
+func BodyLimit(limit int64, skipRoutes ...string) gin.HandlerFunc {
+	skip := make(map[string]bool, len(skipRoutes))
+	for _, route := range skipRoutes {
+		skip[route] = true
+	}
+
+	return func(c *gin.Context) {
+		if limit <= 0 || c.Request.Body == nil || skip[c.FullPath()] {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			body := i18n.Error(c, i18n.BodyTooLarge)
+			body["limit_bytes"] = limit
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, body)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
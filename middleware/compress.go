@@ -0,0 +1,240 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"pic/config"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// compressor 一种压缩编码及其编码器池
+type compressor struct {
+	encoding string
+	pool     sync.Pool
+}
+
+func (cp *compressor) get(w io.Writer) io.WriteCloser {
+	enc := cp.pool.Get().(interface {
+		io.WriteCloser
+		Reset(io.Writer)
+	})
+	enc.Reset(w)
+	return enc
+}
+
+// Compress 按 Accept-Encoding 对响应进行 brotli 或 gzip 压缩（配置热加载后生效）。
+// 小于 compression.min_size 的响应、图片视频等已压缩格式、Range 请求和已编码的响应不压缩。
+func Compress() gin.HandlerFunc {
+	return Reloadable(func(cfg *config.ServerConfig) gin.HandlerFunc {
+		return compressWithConfig(cfg.Compression)
+	})
+}
+
+func compressWithConfig(cfg config.CompressionConfig) gin.HandlerFunc {
+	if !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	br := &compressor{encoding: "br"}
+	br.pool.New = func() interface{} { return brotli.NewWriterLevel(io.Discard, cfg.BrotliLevel) }
+	gz := &compressor{encoding: "gzip"}
+	gz.pool.New = func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, cfg.GzipLevel)
+		return w
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodHead || c.GetHeader("Range") != "" || c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		var cp *compressor
+		switch accept := c.GetHeader("Accept-Encoding"); {
+		case acceptsEncoding(accept, "br"):
+			cp = br
+		case acceptsEncoding(accept, "gzip"):
+			cp = gz
+		default:
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, cp: cp, minSize: cfg.MinSize}
+		c.Writer = w
+		defer func() {
+			if p := recover(); p != nil {
+				// 处理器 panic：丢弃未写出的内容，交还原始 Writer 后继续向外抛出，
+				// 由外层的 Recovery 写出 500（不能先按默认的 200 写出响应头）
+				w.abort()
+				c.Writer = w.ResponseWriter
+				panic(p)
+			}
+			w.close()
+		}()
+		c.Next()
+	}
+}
+
+// acceptsEncoding 判断 Accept-Encoding 是否接受 encoding（q=0 表示拒绝）
+func acceptsEncoding(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compressWriter 先缓冲到 minSize 字节再决定是否压缩，避免压缩很小的响应
+type compressWriter struct {
+	gin.ResponseWriter
+	cp      *compressor
+	minSize int
+
+	buf      []byte
+	decided  bool
+	compress bool
+	enc      io.WriteCloser
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.compress {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// decide 根据状态码和 Content-Type 决定是否压缩，然后写出已缓冲的内容
+func (w *compressWriter) decide(largeEnough bool) error {
+	w.decided = true
+	h := w.ResponseWriter.Header()
+	compressible := w.shouldCompress(h)
+	if compressible {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	w.compress = compressible && largeEnough
+
+	if w.compress {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.cp.encoding)
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			// 压缩后内容不同，强 ETag 改为弱 ETag
+			h.Set("ETag", "W/"+etag)
+		}
+		w.enc = w.cp.get(w.ResponseWriter)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.compress {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *compressWriter) shouldCompress(h http.Header) bool {
+	status := w.ResponseWriter.Status()
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified || status == http.StatusPartialContent {
+		return false
+	}
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	return compressibleType(h.Get("Content-Type"))
+}
+
+// compressibleType 文本类内容可压缩；图片（SVG 除外）、音视频、压缩包等已压缩格式跳过
+func compressibleType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "image/svg+xml",
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml",
+		"application/wasm", "application/manifest+json", "font/ttf", "font/otf":
+		return true
+	}
+	return false
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.compress {
+		if f, ok := w.enc.(interface{ Flush() error }); ok {
+			f.Flush()
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close 写出剩余内容：未达到 minSize 的响应原样写出
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide(false)
+		if !w.ResponseWriter.Written() {
+			w.ResponseWriter.WriteHeaderNow()
+		}
+		return
+	}
+	if w.compress {
+		w.enc.Close()
+		w.cp.pool.Put(w.enc)
+	}
+}
+
+// abort 处理器 panic 时放弃压缩，响应头尚未写出时撤销压缩相关的响应头
+func (w *compressWriter) abort() {
+	w.buf = nil
+	if !w.ResponseWriter.Written() {
+		h := w.ResponseWriter.Header()
+		if h.Get("Content-Encoding") == w.cp.encoding {
+			h.Del("Content-Encoding")
+		}
+	}
+	// 编码器可能处于半写状态，不再放回池中
+	w.enc = nil
+	w.compress = false
+	w.decided = true
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"pic/config"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+func newCompressRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Recovery(), compressWithConfig(config.CompressionConfig{Enabled: true, GzipLevel: 5, BrotliLevel: 4, MinSize: 1024}))
+	r.GET("/", handler)
+	return r
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"name":"pic"}`, 200)
+	for _, tc := range []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+		encoding    string
+	}{
+		{"gzip", "gzip", "application/json", large, "gzip"},
+		{"brotli preferred", "gzip, br", "application/json", large, "br"},
+		{"q=0 refuses", "br;q=0, gzip", "application/json", large, "gzip"},
+		{"no accept", "", "application/json", large, ""},
+		{"below min size", "gzip", "application/json", "{}", ""},
+		{"image skipped", "gzip", "image/png", large, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newCompressRouter(func(c *gin.Context) {
+				c.Data(http.StatusOK, tc.contentType, []byte(tc.body))
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept-Encoding", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tc.encoding {
+				t.Fatalf("Content-Encoding = %q, 期望 %q", got, tc.encoding)
+			}
+			var reader io.Reader = w.Body
+			switch tc.encoding {
+			case "gzip":
+				gz, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				reader = gz
+			case "br":
+				reader = brotli.NewReader(w.Body)
+			}
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.body {
+				t.Fatalf("解压后的响应体与原文不同（%d 字节，期望 %d 字节）", len(got), len(tc.body))
+			}
+		})
+	}
+}
+
+func TestCompressHandlerPanic(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		before string
+	}{
+		{"nothing written", ""},
+		{"buffered below min size", "partial"},
+		{"encoder started", strings.Repeat("x", 4096)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newCompressRouter(func(c *gin.Context) {
+				if tc.before != "" {
+					c.Header("Content-Type", "text/plain")
+					c.Writer.WriteString(tc.before)
+				}
+				panic("boom")
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if len(tc.before) >= 1024 {
+				// 压缩数据已经开始写出时无法再改状态码，只要求不再向已关闭的编码器写入
+				return
+			}
+			if w.Code != http.StatusInternalServerError {
+				t.Fatalf("状态码 = %d, 期望 500", w.Code)
+			}
+			if enc := w.Header().Get("Content-Encoding"); enc != "" {
+				t.Fatalf("500 响应不应带 Content-Encoding: %q", enc)
+			}
+			if !strings.Contains(w.Body.String(), `"code":"internal_error"`) {
+				t.Fatalf("响应体不是 Recovery 的错误 JSON: %s", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"pic/config"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// originMatches 判断来源是否在允许列表中，支持 https://*.example.com 形式的子域通配
+func originMatches(allowedOrigins []string, origin string) bool {
+	for _, allowed := range allowedOrigins {
+		allowed = strings.TrimSuffix(allowed, "/")
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if scheme, host, ok := strings.Cut(allowed, "://*."); ok {
+			prefix := scheme + "://"
+			if strings.HasPrefix(strings.ToLower(origin), strings.ToLower(prefix)) &&
+				strings.HasSuffix(strings.ToLower(origin), "."+strings.ToLower(host)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CORSWithConfig 按配置处理跨域请求和预检请求
+func CORSWithConfig(cfg config.CORSConfig) gin.HandlerFunc {
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	exposed := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(cfg.MaxAge)
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !originMatches(cfg.AllowedOrigins, origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			// 非预检请求照常处理，浏览器因缺少 CORS 头而拒绝读取响应
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if exposed != "" {
+			h.Set("Access-Control-Expose-Headers", exposed)
+		}
+
+		if preflight {
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			h.Set("Access-Control-Max-Age", maxAge)
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+	}
+}
+
+// CORSFromConfig 使用服务器配置中的跨域策略，配置热加载后生效
+func CORSFromConfig() gin.HandlerFunc {
+	return Reloadable(func(cfg *config.ServerConfig) gin.HandlerFunc {
+		return CORSWithConfig(cfg.CORS)
+	})
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"pic/config"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOriginMatches(t *testing.T) {
+	allowed := []string{"https://app.example.com/", "https://*.example.org", "http://localhost:5173"}
+	for _, tc := range []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://app.example.com", false},
+		{"https://evil.example.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"http://a.example.org", false},
+		{"https://a.example.org.evil.com", false},
+		{"https://evilexample.org", false},
+		{"http://localhost:5173", true},
+		{"http://localhost:3000", false},
+	} {
+		if got := originMatches(allowed, tc.origin); got != tc.want {
+			t.Errorf("originMatches(%q) = %v, 期望 %v", tc.origin, got, tc.want)
+		}
+	}
+	if !originMatches([]string{"*"}, "https://anything.example") {
+		t.Error("* 应匹配所有来源")
+	}
+}
+
+func TestCORSWithConfig(t *testing.T) {
+	cfg := config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	}
+	for _, tc := range []struct {
+		name        string
+		method      string
+		origin      string
+		preflight   bool
+		status      int
+		allowOrigin string
+		handled     bool
+	}{
+		{"same origin", http.MethodGet, "", false, http.StatusOK, "", true},
+		{"allowed", http.MethodGet, "https://app.example.com", false, http.StatusOK, "https://app.example.com", true},
+		{"disallowed still handled", http.MethodGet, "https://evil.example.com", false, http.StatusOK, "", true},
+		{"preflight allowed", http.MethodOptions, "https://app.example.com", true, http.StatusNoContent, "https://app.example.com", false},
+		{"preflight disallowed", http.MethodOptions, "https://evil.example.com", true, http.StatusForbidden, "", false},
+		{"options without preflight header", http.MethodOptions, "https://app.example.com", false, http.StatusOK, "https://app.example.com", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			handled := false
+			r := gin.New()
+			r.Use(CORSWithConfig(cfg))
+			r.Handle(tc.method, "/", func(c *gin.Context) {
+				handled = true
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(tc.method, "/", nil)
+			if tc.origin != "" {
+				req.Header.Set("Origin", tc.origin)
+			}
+			if tc.preflight {
+				req.Header.Set("Access-Control-Request-Method", "POST")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			h := w.Header()
+			if w.Code != tc.status || handled != tc.handled {
+				t.Fatalf("状态码 = %d 处理器执行 = %v, 期望 %d %v", w.Code, handled, tc.status, tc.handled)
+			}
+			if got := h.Get("Access-Control-Allow-Origin"); got != tc.allowOrigin {
+				t.Fatalf("Access-Control-Allow-Origin = %q, 期望 %q", got, tc.allowOrigin)
+			}
+			if tc.origin != "" && h.Get("Vary") != "Origin" {
+				t.Fatalf("带 Origin 的响应缺少 Vary: Origin")
+			}
+			if tc.allowOrigin == "" {
+				if h.Get("Access-Control-Allow-Credentials") != "" {
+					t.Fatal("未允许的来源不应带 Allow-Credentials")
+				}
+				return
+			}
+			if h.Get("Access-Control-Allow-Credentials") != "true" || h.Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+				t.Fatalf("缺少凭据或暴露头: %v", h)
+			}
+			if tc.preflight && (h.Get("Access-Control-Allow-Methods") != "GET, POST" ||
+				h.Get("Access-Control-Allow-Headers") != "Authorization" || h.Get("Access-Control-Max-Age") != "600") {
+				t.Fatalf("预检响应头错误: %v", h)
+			}
+		})
+	}
+}
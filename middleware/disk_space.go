@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+	"pic/config"
+	"pic/diskusage"
+	"pic/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DiskSpaceGuard 本地存储剩余空间低于 storage.min_free_mb 时拒绝上传（507）
+func DiskSpaceGuard() gin.HandlerFunc {
+	return diskSpaceGuard(diskusage.Latest, func() int { return config.Current().Storage.MinFreeMB })
+}
+
+func diskSpaceGuard(latest func() (diskusage.Usage, bool), minFreeMB func() int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		minFree := uint64(minFreeMB()) << 20
+		if u, ok := latest(); ok && minFree > 0 && u.FreeBytes < minFree {
+			c.AbortWithStatusJSON(http.StatusInsufficientStorage, i18n.Error(c, i18n.InsufficientStorage))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"pic/diskusage"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDiskSpaceGuard(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		checked   bool
+		freeMB    uint64
+		minFreeMB int
+		status    int
+	}{
+		{"not monitored", false, 0, 500, http.StatusOK},
+		{"enough space", true, 600, 500, http.StatusOK},
+		{"low space", true, 100, 500, http.StatusInsufficientStorage},
+		{"min_free_mb 0 only monitors", true, 0, 0, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			latest := func() (diskusage.Usage, bool) {
+				return diskusage.Usage{TotalBytes: 1000 << 20, FreeBytes: tc.freeMB << 20}, tc.checked
+			}
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.POST("/upload", diskSpaceGuard(latest, func() int { return tc.minFreeMB }), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload", nil))
+			if w.Code != tc.status {
+				t.Fatalf("状态码 %d, 期望 %d: %s", w.Code, tc.status, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"pic/config"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bufferWriter 暂存响应体，由中间件决定写出完整响应还是 304
+type bufferWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *bufferWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// WriteHeaderNow 推迟到中间件写出响应时，使其仍能补充响应头
+func (w *bufferWriter) WriteHeaderNow() {}
+
+// ConditionalGET 为 GET 请求的 200 响应加上 ETag 和 Cache-Control（取自当前配置），
+// 请求的 If-None-Match 匹配时返回 304 且不传输响应体。
+// 处理器或 LastModified 设置了 Last-Modified 时同样支持 If-Modified-Since。
+func ConditionalGET(cacheControl func(*config.ServerConfig) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		w := &bufferWriter{ResponseWriter: original}
+		c.Writer = w
+		defer func() {
+			if p := recover(); p != nil {
+				// 处理器 panic：丢弃缓冲的内容，交还原始 Writer 后继续向外抛出，
+				// 否则 Recovery 的 500 响应会写进这里不再输出的缓冲区
+				w.buf.Reset()
+				c.Writer = original
+				panic(p)
+			}
+		}()
+		c.Next()
+		c.Writer = original
+
+		body := w.buf.Bytes()
+		if original.Status() == http.StatusNotModified {
+			// 后面的 LastModified 已按修改水位判定未修改
+			if cc := cacheControl(config.Current()); cc != "" {
+				original.Header().Set("Cache-Control", cc)
+			}
+			original.WriteHeaderNow()
+			return
+		}
+		if original.Status() != http.StatusOK {
+			original.Write(body)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		h := original.Header()
+		h.Set("ETag", etag)
+		if cc := cacheControl(config.Current()); cc != "" {
+			h.Set("Cache-Control", cc)
+		}
+
+		if notModified(c.Request, etag, h.Get("Last-Modified")) {
+			h.Del("Content-Type")
+			h.Del("Content-Length")
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+		original.Write(body)
+	}
+}
+
+// notModified 按 RFC 9110：有 If-None-Match 时只比较 ETag（弱比较），否则比较 If-Modified-Since
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified == "" {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	return err == nil && !modified.Truncate(time.Second).After(ims)
+}
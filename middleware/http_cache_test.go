@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"pic/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestConditionalGET(t *testing.T) {
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ConditionalGET(func(*config.ServerConfig) string { return "public, max-age=60" }))
+	r.GET("/ok", func(c *gin.Context) {
+		c.Header("Last-Modified", modified.Format(http.TimeFormat))
+		c.String(http.StatusOK, "hello")
+	})
+	r.GET("/missing", func(c *gin.Context) { c.String(http.StatusNotFound, "missing") })
+	r.POST("/ok", func(c *gin.Context) { c.String(http.StatusOK, "posted") })
+
+	do := func(method, path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	etag := do(http.MethodGet, "/ok", nil).Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("200 响应缺少 ETag")
+	}
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		header map[string]string
+		status int
+		body   string
+		etag   bool
+	}{
+		{"no validators", http.MethodGet, "/ok", nil, http.StatusOK, "hello", true},
+		{"etag match", http.MethodGet, "/ok", map[string]string{"If-None-Match": etag}, http.StatusNotModified, "", true},
+		{"strong form matches weak etag", http.MethodGet, "/ok", map[string]string{"If-None-Match": etag[2:]}, http.StatusNotModified, "", true},
+		{"etag in list", http.MethodGet, "/ok", map[string]string{"If-None-Match": `"other", ` + etag}, http.StatusNotModified, "", true},
+		{"wildcard", http.MethodGet, "/ok", map[string]string{"If-None-Match": "*"}, http.StatusNotModified, "", true},
+		{"etag mismatch", http.MethodGet, "/ok", map[string]string{"If-None-Match": `W/"other"`}, http.StatusOK, "hello", true},
+		{"not modified since", http.MethodGet, "/ok", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusNotModified, "", true},
+		{"modified since", http.MethodGet, "/ok", map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, http.StatusOK, "hello", true},
+		{"if-none-match wins over if-modified-since", http.MethodGet, "/ok", map[string]string{
+			"If-None-Match": `W/"other"`, "If-Modified-Since": modified.Format(http.TimeFormat),
+		}, http.StatusOK, "hello", true},
+		{"error passes through", http.MethodGet, "/missing", map[string]string{"If-None-Match": "*"}, http.StatusNotFound, "missing", false},
+		{"post untouched", http.MethodPost, "/ok", map[string]string{"If-None-Match": "*"}, http.StatusOK, "posted", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := do(tc.method, tc.path, tc.header)
+			if w.Code != tc.status || w.Body.String() != tc.body {
+				t.Fatalf("响应 = %d %q, 期望 %d %q", w.Code, w.Body, tc.status, tc.body)
+			}
+			if got := w.Header().Get("ETag"); (got != "") != tc.etag || (tc.etag && got != etag) {
+				t.Fatalf("ETag = %q", got)
+			}
+			if tc.etag && w.Header().Get("Cache-Control") != "public, max-age=60" {
+				t.Fatalf("Cache-Control = %q", w.Header().Get("Cache-Control"))
+			}
+			if w.Code == http.StatusNotModified && w.Header().Get("Content-Type") != "" {
+				t.Fatalf("304 响应不应带 Content-Type: %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// 没有 Accept-Encoding 时 Compress 不介入，处理器 panic 后 Recovery 的 500 JSON 不能被缓冲区吞掉
+func TestConditionalGETHandlerPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Recovery(), ConditionalGET(func(*config.ServerConfig) string { return "public, max-age=60" }))
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		panic("boom")
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("状态码 = %d, 期望 500", w.Code)
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"code":"internal_error"`) || strings.Contains(body, "partial") {
+		t.Fatalf("响应体不是 Recovery 的错误 JSON: %q", body)
+	}
+	if w.Header().Get("ETag") != "" {
+		t.Fatal("500 响应不应带 ETag")
+	}
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"pic/cache"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// watermarkTTL 水位记录的保留时间，过期后按当前时间重新开始
+const watermarkTTL = 30 * 24 * time.Hour
+
+// modifiedKey MarkModified 记录的本次请求修改的资源，命名空间 -> 资源 ID
+const modifiedKey = "modified_resources"
+
+func watermarkKey(namespace, resource string) string {
+	if resource == "" {
+		return "watermark:" + namespace
+	}
+	return "watermark:" + namespace + ":" + resource
+}
+
+// touchWatermark 将命名空间（resource 为空时）或其中某个资源的最后修改时间记为当前时间
+func touchWatermark(ctx context.Context, store cache.Store, namespace, resource string) {
+	store.Set(ctx, watermarkKey(namespace, resource), []byte(strconv.FormatInt(time.Now().UnixNano(), 10)), watermarkTTL)
+}
+
+func readWatermark(ctx context.Context, store cache.Store, namespace, resource string) (time.Time, bool) {
+	raw, ok := store.Get(ctx, watermarkKey(namespace, resource))
+	if !ok {
+		return time.Time{}, false
+	}
+	ns, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ns), true
+}
+
+// watermark 读取资源的最后修改时间：命名空间水位与资源水位中较晚的一个。
+// 水位记录缺失时无法区分是从未修改、已过期还是被缓存淘汰（内存缓存的 LRU 与响应缓存共用容量，
+// Redis 在 maxmemory 下也会淘汰），被淘汰的记录可能比其它记录都新，因此一律从当前时间重新开始记录，
+// 代价只是客户端多拉取一次完整响应。
+func watermark(ctx context.Context, store cache.Store, namespace, resource string) time.Time {
+	modified, ok := readWatermark(ctx, store, namespace, "")
+	if !ok {
+		touchWatermark(ctx, store, namespace, "")
+		modified = time.Now()
+	}
+	if resource != "" {
+		t, ok := readWatermark(ctx, store, namespace, resource)
+		if !ok {
+			touchWatermark(ctx, store, namespace, resource)
+			t = time.Now()
+		}
+		if t.After(modified) {
+			modified = t
+		}
+	}
+	return modified
+}
+
+// MarkModified 记录本次请求修改了命名空间中的哪个资源（如图库 slug），
+// 请求成功后 InvalidateCache 只更新这些资源的水位，没有标记时更新整个命名空间
+func MarkModified(c *gin.Context, namespace, resource string) {
+	if resource == "" {
+		return
+	}
+	marked, _ := c.Get(modifiedKey)
+	m, _ := marked.(map[string][]string)
+	if m == nil {
+		m = map[string][]string{}
+		c.Set(modifiedKey, m)
+	}
+	m[namespace] = append(m[namespace], resource)
+}
+
+// ModifiesResource 将 key 返回的资源标记为本次请求修改的资源，用于资源可以从请求本身得出的路由组
+func ModifiesResource(namespace string, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		MarkModified(c, namespace, key(c))
+		c.Next()
+	}
+}
+
+// LastModified 以资源的修改水位作为 GET 响应的 Last-Modified（由 InvalidateCache 在写操作成功后更新），
+// key 返回请求的资源（如图库 slug 或用户 ID），为 nil 或返回空时只看整个命名空间的水位。
+// 请求只带 If-Modified-Since 且水位之后没有写操作时直接返回 304，不再执行处理器；
+// 带 If-None-Match 的请求交给 ConditionalGET 按内容比较。
+//
+// 水位保存在 cache.Default 中：多实例部署必须配置 Redis，否则进程内缓存只能看到本实例上的写操作，
+// 其它实例会对已修改的资源继续返回 304。
+func LastModified(namespace string, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		store := cache.Default
+		if store == nil || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		var resource string
+		if key != nil {
+			resource = key(c)
+		}
+		modified := watermark(c.Request.Context(), store, namespace, resource)
+		// 水位落在当前这一秒内时，同一秒内的后续修改无法用秒级的 HTTP 时间区分，这次不给出 Last-Modified
+		if !modified.Before(time.Now().Truncate(time.Second)) {
+			c.Next()
+			return
+		}
+		lastModified := modified.UTC().Format(http.TimeFormat)
+		c.Header("Last-Modified", lastModified)
+
+		if c.GetHeader("If-None-Match") == "" && notModified(c.Request, "", lastModified) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pic/cache"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLastModifiedPerResource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := cache.NewMemoryStore(ctx, 100)
+	cache.Default = store
+	t.Cleanup(func() { cache.Default = nil })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	slug := func(c *gin.Context) string { return c.Param("slug") }
+	r.GET("/gallery/:slug", LastModified("gallery", slug), func(c *gin.Context) { c.String(http.StatusOK, c.Param("slug")) })
+	r.POST("/gallery/:slug", InvalidateCache("gallery"), func(c *gin.Context) {
+		MarkModified(c, "gallery", c.Param("slug"))
+		c.Status(http.StatusNoContent)
+	})
+	r.POST("/all", InvalidateCache("gallery"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	// 水位设在 10 秒前，客户端持有当时的 Last-Modified
+	past := time.Now().Add(-10 * time.Second)
+	for _, key := range []string{"", "alice", "bob"} {
+		setWatermark(ctx, store, "gallery", key, past)
+	}
+	since := past.UTC().Format(http.TimeFormat)
+
+	get := func(slug string) int {
+		req := httptest.NewRequest(http.MethodGet, "/gallery/"+slug, nil)
+		req.Header.Set("If-Modified-Since", since)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	post := func(path string) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("POST %s = %d", path, w.Code)
+		}
+	}
+
+	for _, slug := range []string{"alice", "bob"} {
+		if code := get(slug); code != http.StatusNotModified {
+			t.Fatalf("未修改的图库 %s 返回 %d，期望 304", slug, code)
+		}
+	}
+
+	// 只修改 alice：bob 仍然是 304
+	post("/gallery/alice")
+	if code := get("alice"); code != http.StatusOK {
+		t.Fatalf("已修改的图库 alice 返回 %d，期望 200", code)
+	}
+	if code := get("bob"); code != http.StatusNotModified {
+		t.Fatalf("未修改的图库 bob 返回 %d，期望 304", code)
+	}
+
+	// 未标记资源的写操作更新整个命名空间
+	post("/all")
+	if code := get("bob"); code != http.StatusOK {
+		t.Fatalf("命名空间修改后 bob 返回 %d，期望 200", code)
+	}
+}
+
+func setWatermark(ctx context.Context, store cache.Store, namespace, resource string, t time.Time) {
+	store.Set(ctx, watermarkKey(namespace, resource), []byte(strconv.FormatInt(t.UnixNano(), 10)), time.Hour)
+}
+
+// 资源水位被淘汰（而不是过期）时可能比命名空间水位新，不能退回命名空间水位给出 304
+func TestLastModifiedEvicted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	const capacity = 4
+	store := cache.NewMemoryStore(ctx, capacity)
+	cache.Default = store
+	t.Cleanup(func() { cache.Default = nil })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/gallery/:slug", LastModified("gallery", func(c *gin.Context) string { return c.Param("slug") }),
+		func(c *gin.Context) { c.String(http.StatusOK, c.Param("slug")) })
+	get := func(since time.Time) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/gallery/alice", nil)
+		req.Header.Set("If-Modified-Since", since.UTC().Format(http.TimeFormat))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 客户端持有 20 秒前的版本，alice 在 10 秒前被修改
+	old, modified := time.Now().Add(-20*time.Second), time.Now().Add(-10*time.Second)
+	setWatermark(ctx, store, "gallery", "", old)
+	setWatermark(ctx, store, "gallery", "alice", modified)
+	if w := get(old); w.Code != http.StatusOK {
+		t.Fatalf("修改后的图库返回 %d，期望 200", w.Code)
+	}
+
+	// 响应缓存写满容量，把 alice 的水位挤出 LRU；命名空间水位刚被读过，仍然保留
+	store.Get(ctx, watermarkKey("gallery", ""))
+	for i := 0; i < capacity-1; i++ {
+		store.Set(ctx, "response:"+strconv.Itoa(i), []byte("body"), time.Hour)
+	}
+	if _, ok := store.Get(ctx, watermarkKey("gallery", "alice")); ok {
+		t.Fatal("测试前提不成立：alice 的水位没有被淘汰")
+	}
+	if _, ok := store.Get(ctx, watermarkKey("gallery", "")); !ok {
+		t.Fatal("测试前提不成立：命名空间水位被淘汰")
+	}
+
+	if w := get(old); w.Code != http.StatusOK {
+		t.Fatalf("资源水位被淘汰后返回 %d，期望 200", w.Code)
+	}
+	if _, ok := store.Get(ctx, watermarkKey("gallery", "alice")); !ok {
+		t.Fatal("缺失的资源水位没有按当前时间重新写入")
+	}
+}
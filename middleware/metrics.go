@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"pic/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 记录请求耗时以及上传次数/字节数
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// 未匹配的路由（静态文件、SPA 回退）合并为一个标签，避免标签爆炸
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		metrics.RequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+
+		if c.Request.Method == http.MethodPost && route == "/api/upload" {
+			if status >= 200 && status < 300 {
+				metrics.UploadsTotal.WithLabelValues("success").Inc()
+				if c.Request.ContentLength > 0 {
+					metrics.UploadBytesTotal.Add(float64(c.Request.ContentLength))
+				}
+			} else {
+				metrics.UploadsTotal.WithLabelValues("failure").Inc()
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"pic/config"
+	"pic/i18n"
+	"pic/ratelimit"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitConfig 各类接口的限流配置
+type RateLimitConfig struct {
+	// Auth 登录/注册，按 IP
+	Auth ratelimit.Limit
+	// Upload 上传，按用户
+	Upload ratelimit.Limit
+	// Gallery 公开图库，按 IP
+	Gallery ratelimit.Limit
+	// API 需要认证的接口，按 API 密钥或会话
+	API ratelimit.Limit
+}
+
+// NewRateLimitConfig 解析配置中的限额字符串
+func NewRateLimitConfig(cfg config.RateLimitConfig) (RateLimitConfig, error) {
+	var out RateLimitConfig
+	for _, item := range []struct {
+		value string
+		dst   *ratelimit.Limit
+	}{
+		{cfg.Auth, &out.Auth},
+		{cfg.Upload, &out.Upload},
+		{cfg.Gallery, &out.Gallery},
+		{cfg.API, &out.API},
+	} {
+		limit, err := ratelimit.ParseLimit(item.value)
+		if err != nil {
+			return out, err
+		}
+		*item.dst = limit
+	}
+	return out, nil
+}
+
+// limit 返回 name 对应的限额，未知名称不限流
+func (cfg RateLimitConfig) limit(name string) ratelimit.Limit {
+	switch name {
+	case "auth":
+		return cfg.Auth
+	case "upload":
+		return cfg.Upload
+	case "gallery":
+		return cfg.Gallery
+	case "api":
+		return cfg.API
+	}
+	return ratelimit.Limit{}
+}
+
+var (
+	rateLimiter ratelimit.Limiter
+	rateLimits  atomic.Pointer[RateLimitConfig]
+)
+
+// SetRateLimiter 设置限流存储（进程内或 Redis），未设置时不限流
+func SetRateLimiter(l ratelimit.Limiter) {
+	rateLimiter = l
+}
+
+// SetRateLimits 设置各接口的限额，配置热加载后再次调用即可生效
+func SetRateLimits(cfg RateLimitConfig) {
+	rateLimits.Store(&cfg)
+}
+
+// currentLimit 返回 name 当前的限额，未设置限流存储时不限流
+func currentLimit(name string) ratelimit.Limit {
+	cfg := rateLimits.Load()
+	if rateLimiter == nil || cfg == nil {
+		return ratelimit.Limit{}
+	}
+	return cfg.limit(name)
+}
+
+// RateLimit 令牌桶限流，限额取自 SetRateLimits 中的同名配置（auth/upload/gallery/api）。
+// 响应带 X-RateLimit-Limit/Remaining/Reset（Reset 为额度恢复满所需秒数），
+// 同一请求经过多个限流时以最内层为准；超限时返回 429 和 Retry-After
+func RateLimit(name string, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := currentLimit(name)
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+
+		res := rateLimiter.Allow(c.Request.Context(), name+":"+key(c), limit)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(res.ResetAfter.Seconds()))))
+		if !res.Allowed {
+			retryAfter := int(math.Ceil(res.RetryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			body := i18n.Error(c, i18n.RateLimited)
+			body["retry_after"] = retryAfter
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, body)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitState 调用方在某个限额下的剩余额度
+type RateLimitState struct {
+	Limit     int `json:"limit"`
+	Remaining int `json:"remaining"`
+	// ResetAfter 额度恢复满所需秒数
+	ResetAfter int `json:"reset_after"`
+}
+
+// RateLimitStatus 查询当前请求在各限额下的剩余额度，keys 为限额名 -> 路由上 RateLimit 使用的 key，
+// 不消耗令牌；未启用的限额不返回
+func RateLimitStatus(c *gin.Context, keys map[string]func(*gin.Context) string) map[string]RateLimitState {
+	states := make(map[string]RateLimitState, len(keys))
+	for name, key := range keys {
+		limit := currentLimit(name)
+		if !limit.Enabled() {
+			continue
+		}
+		res := rateLimiter.Peek(c.Request.Context(), name+":"+key(c), limit)
+		states[name] = RateLimitState{
+			Limit:      limit.Burst,
+			Remaining:  res.Remaining,
+			ResetAfter: int(math.Ceil(res.ResetAfter.Seconds())),
+		}
+	}
+	return states
+}
+
+// ClientIPKey 按客户端 IP 限流
+func ClientIPKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// CredentialOrIPKey 按请求携带的凭据（Authorization 的哈希）限流，同一用户的每个 API 密钥或会话各有独立额度；
+// 没有凭据时按 IP
+func CredentialOrIPKey(c *gin.Context) string {
+	if k := SessionCacheKey(c); k != "" {
+		return "session:" + k
+	}
+	return ClientIPKey(c)
+}
+
+// UserIDKey AuthMiddleware 写入 gin.Context 的当前用户 ID
+const UserIDKey = "user_id"
+
+// userID 当前用户 ID，未认证或 AuthMiddleware 未提供用户 ID 时为空
+func userID(c *gin.Context) string {
+	if id, ok := c.Get(UserIDKey); ok {
+		return fmt.Sprint(id)
+	}
+	return ""
+}
+
+// UserOrSessionKey 请求所属的用户：已登录时按用户 ID（同一用户的多个会话视为同一个），
+// 其次按 Authorization 的哈希，都没有时为空。限流、修改水位和公告关闭记录都按它区分用户
+func UserOrSessionKey(c *gin.Context) string {
+	if id := userID(c); id != "" {
+		return "user:" + id
+	}
+	if k := SessionCacheKey(c); k != "" {
+		return "session:" + k
+	}
+	return ""
+}
+
+// UserOrIPKey 按 UserOrSessionKey 限流，无法识别用户时按 IP
+func UserOrIPKey(c *gin.Context) string {
+	if k := UserOrSessionKey(c); k != "" {
+		return k
+	}
+	return ClientIPKey(c)
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pic/ratelimit"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitKeys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	limit, err := ratelimit.ParseLimit("2/h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetRateLimiter(ratelimit.NewMemoryLimiter(ctx))
+	SetRateLimits(RateLimitConfig{API: limit, Upload: limit})
+	t.Cleanup(func() { SetRateLimiter(nil) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// 模拟 AuthMiddleware：Authorization 为 "<用户>:<密钥>"，用户部分写入 user_id
+	r.Use(func(c *gin.Context) {
+		if user, _, ok := strings.Cut(c.GetHeader("Authorization"), ":"); ok {
+			c.Set(UserIDKey, user)
+		}
+	})
+	r.GET("/api", RateLimit("api", CredentialOrIPKey), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	r.POST("/upload", RateLimit("upload", UserOrIPKey), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	do := func(method, path, auth, ip string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":1234"
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	expect := func(method, path, auth, ip string, want int) {
+		t.Helper()
+		if code := do(method, path, auth, ip); code != want {
+			t.Fatalf("%s %s %s: 状态码 %d, 期望 %d", method, path, auth, code, want)
+		}
+	}
+
+	// api 额度按密钥计算：同一用户的两个密钥互不影响，换 IP 也不会重置
+	expect(http.MethodGet, "/api", "alice:k1", "10.0.0.1", http.StatusNoContent)
+	expect(http.MethodGet, "/api", "alice:k1", "10.0.0.2", http.StatusNoContent)
+	expect(http.MethodGet, "/api", "alice:k1", "10.0.0.3", http.StatusTooManyRequests)
+	expect(http.MethodGet, "/api", "alice:k2", "10.0.0.1", http.StatusNoContent)
+	expect(http.MethodGet, "/api", "alice:k2", "10.0.0.1", http.StatusNoContent)
+	expect(http.MethodGet, "/api", "", "10.0.0.1", http.StatusNoContent)
+
+	// 上传额度按用户计算：换密钥、换 IP 都共用额度
+	for i, auth := range []string{"alice:k1", "alice:k2", "alice:k3"} {
+		want := http.StatusNoContent
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		expect(http.MethodPost, "/upload", auth, "10.0.0."+strconv.Itoa(i+1), want)
+	}
+	expect(http.MethodPost, "/upload", "bob:k1", "10.0.0.1", http.StatusNoContent)
+	expect(http.MethodPost, "/upload", "", "10.0.0.1", http.StatusNoContent)
+}
+
+func TestUserOrSessionKey(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	if got := UserOrSessionKey(c); got != "" {
+		t.Fatalf("未认证时 UserOrSessionKey = %q", got)
+	}
+	c.Request.Header.Set("Authorization", "Bearer k1")
+	if got := UserOrSessionKey(c); got != "session:"+SessionCacheKey(c) {
+		t.Fatalf("没有用户 ID 时 UserOrSessionKey = %q", got)
+	}
+	c.Set(UserIDKey, uint(42))
+	if got := UserOrSessionKey(c); got != "user:42" {
+		t.Fatalf("UserOrSessionKey = %q, 期望 user:42", got)
+	}
+}
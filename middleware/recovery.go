@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"pic/i18n"
+	"pic/logger"
+	"pic/reporting"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery 捕获处理器中的 panic，记录调用栈和请求上下文并上报（Sentry/Webhook），
+// 再返回 500 JSON。处理器通过 c.Error 记录且最终响应 5xx 的错误同样会被上报。
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// 客户端断开连接不算服务端错误，也无法再写响应
+			if isBrokenPipe(recovered) {
+				c.Abort()
+				return
+			}
+
+			stack := string(debug.Stack())
+			requestID := c.GetString(RequestIDKey)
+			logger.FromContext(c.Request.Context()).Error("panic recovered",
+				"panic", fmt.Sprint(recovered),
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"stack", stack,
+			)
+			reporting.ReportPanic(c.Request, recovered,
+				reporting.NewEvent(c.Request, fmt.Sprint(recovered), stack, requestID, c.ClientIP()))
+
+			body := i18n.Error(c, i18n.InternalError)
+			body["request_id"] = requestID
+			c.AbortWithStatusJSON(http.StatusInternalServerError, body)
+		}()
+
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusInternalServerError && len(c.Errors) > 0 {
+			err := c.Errors.Last().Err
+			reporting.ReportError(c.Request, err,
+				reporting.NewEvent(c.Request, err.Error(), "", c.GetString(RequestIDKey), c.ClientIP()))
+		}
+	}
+}
+
+func isBrokenPipe(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+	if errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		var sysErr *os.SyscallError
+		if errors.As(opErr.Err, &sysErr) {
+			msg := strings.ToLower(sysErr.Error())
+			return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+		}
+	}
+	return false
+}
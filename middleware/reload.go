@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"pic/config"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reloadable 用当前服务器配置构建中间件，配置热加载后重新构建，新请求立即使用新配置
+func Reloadable(build func(*config.ServerConfig) gin.HandlerFunc) gin.HandlerFunc {
+	var handler atomic.Pointer[gin.HandlerFunc]
+	store := func(cfg *config.ServerConfig) {
+		h := build(cfg)
+		handler.Store(&h)
+	}
+	store(config.Current())
+	config.OnReload(store)
+
+	return func(c *gin.Context) {
+		(*handler.Load())(c)
+	}
+}
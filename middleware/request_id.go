@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"pic/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 请求 ID 的请求/响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey gin.Context 中保存请求 ID 的键
+const RequestIDKey = "request_id"
+
+// RequestID 为每个请求分配 ID（沿用客户端或上游代理传入的合法 ID），
+// 写入响应头，并放进请求 context 供 logger.FromContext 使用
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validRequestID 只接受长度适中、由字母数字和 -_. 组成的 ID，防止日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"pic/cache"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cachedResponse 缓存中保存的响应
+type cachedResponse struct {
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// cacheWriter 在写出响应的同时保留一份副本
+type cacheWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheWriter) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// CacheResponse 缓存 GET 请求的 200 响应。key 为空字符串时跳过缓存。
+// 缓存键附带规范化后的查询参数（按参数名排序），分页等参数不同的请求分别缓存。
+// 命名空间内的缓存由 InvalidateCache 统一失效。
+func CacheResponse(namespace string, ttl time.Duration, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		store := cache.Default
+		if store == nil || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		k := key(c)
+		query, err := url.ParseQuery(c.Request.URL.RawQuery)
+		if k == "" || err != nil {
+			c.Next()
+			return
+		}
+		if len(query) > 0 {
+			k += "?" + query.Encode()
+		}
+
+		ctx := c.Request.Context()
+		fullKey := namespace + ":" + strconv.FormatInt(store.Generation(ctx, namespace), 10) + ":" + k
+
+		if raw, ok := store.Get(ctx, fullKey); ok {
+			var cached cachedResponse
+			if json.Unmarshal(raw, &cached) == nil {
+				c.Header("X-Cache", "HIT")
+				c.Data(http.StatusOK, cached.ContentType, cached.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		w := &cacheWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer func() {
+			// panic 时同样交还原始 Writer，由外层的 Recovery 写出 500，响应不会被缓存
+			c.Writer = w.ResponseWriter
+		}()
+		c.Header("X-Cache", "MISS")
+		c.Next()
+
+		if w.Status() != http.StatusOK || c.IsAborted() {
+			return
+		}
+		raw, err := json.Marshal(cachedResponse{ContentType: w.Header().Get("Content-Type"), Body: w.buf.Bytes()})
+		if err == nil {
+			store.Set(ctx, fullKey, raw, ttl)
+		}
+	}
+}
+
+// InvalidateCache 非 GET 请求成功后使给定命名空间的缓存失效，并更新修改水位（见 LastModified）：
+// 请求通过 MarkModified 标记了修改的资源时只更新这些资源，否则更新整个命名空间
+func InvalidateCache(namespaces ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		store := cache.Default
+		if store == nil || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			return
+		}
+		if status := c.Writer.Status(); status < 200 || status >= 300 {
+			return
+		}
+		marked, _ := c.Get(modifiedKey)
+		resources, _ := marked.(map[string][]string)
+		for _, ns := range namespaces {
+			store.Bump(c.Request.Context(), ns)
+			if len(resources[ns]) == 0 {
+				touchWatermark(c.Request.Context(), store, ns, "")
+				continue
+			}
+			for _, r := range resources[ns] {
+				touchWatermark(c.Request.Context(), store, ns, r)
+			}
+		}
+	}
+}
+
+// SessionCacheKey 以 Authorization 头的哈希作为缓存键，用于按会话缓存的接口
+func SessionCacheKey(c *gin.Context) string {
+	auth := c.GetHeader("Authorization")
+	if auth == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(auth))
+	return hex.EncodeToString(sum[:])
+}
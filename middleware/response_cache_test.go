@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pic/cache"
+	"pic/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCacheResponseQuery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.Default = cache.NewMemoryStore(ctx, 100)
+	t.Cleanup(func() { cache.Default = nil })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	calls := 0
+	r.GET("/gallery/:slug",
+		ConditionalGET(func(*config.ServerConfig) string { return "" }),
+		CacheResponse("gallery", time.Minute, func(c *gin.Context) string { return c.Param("slug") }),
+		func(c *gin.Context) {
+			calls++
+			c.String(http.StatusOK, "page="+c.Query("page")+"&size="+c.Query("size"))
+		})
+
+	for _, tc := range []struct {
+		path  string
+		body  string
+		cache string
+	}{
+		{"/gallery/a", "page=&size=", "MISS"},
+		{"/gallery/a?page=2", "page=2&size=", "MISS"},
+		{"/gallery/a?page=3", "page=3&size=", "MISS"},
+		{"/gallery/a?page=2", "page=2&size=", "HIT"},
+		{"/gallery/a", "page=&size=", "HIT"},
+		// 参数顺序不同视为同一请求
+		{"/gallery/a?page=2&size=10", "page=2&size=10", "MISS"},
+		{"/gallery/a?size=10&page=2", "page=2&size=10", "HIT"},
+		{"/gallery/b?page=2", "page=2&size=", "MISS"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Body.String() != tc.body || w.Header().Get("X-Cache") != tc.cache {
+			t.Fatalf("GET %s = %q (X-Cache %s)，期望 %q (%s)", tc.path, w.Body, w.Header().Get("X-Cache"), tc.body, tc.cache)
+		}
+	}
+	if calls != 5 {
+		t.Fatalf("处理器执行了 %d 次，期望 5 次", calls)
+	}
+
+	// 不同页的 ETag 也不同，If-None-Match 不会跨页命中
+	first := httptest.NewRecorder()
+	r.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/gallery/a?page=2", nil))
+	req := httptest.NewRequest(http.MethodGet, "/gallery/a?page=3", nil)
+	req.Header.Set("If-None-Match", first.Header().Get("ETag"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "page=3&size=" {
+		t.Fatalf("第 3 页带第 2 页的 ETag 返回 %d %q", w.Code, w.Body)
+	}
+}
+
+// 无论处理器正常返回还是 panic，CacheResponse 都要交还原始 Writer；panic 的响应不能被缓存
+func TestCacheResponseRestoresWriter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cache.Default = cache.NewMemoryStore(ctx, 100)
+	t.Cleanup(func() { cache.Default = nil })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	calls := 0
+	r.Use(Recovery(), func(c *gin.Context) {
+		c.Next()
+		if _, ok := c.Writer.(*cacheWriter); ok {
+			t.Error("CacheResponse 返回后 c.Writer 仍是 cacheWriter")
+		}
+	})
+	r.GET("/", CacheResponse("test", time.Minute, func(*gin.Context) string { return "k" }), func(c *gin.Context) {
+		calls++
+		if c.Query("panic") != "" {
+			panic("boom")
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	for i, path := range []string{"/?panic=1", "/?panic=1", "/", "/"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if path == "/" {
+			if w.Code != http.StatusOK || w.Body.String() != "ok" {
+				t.Fatalf("第 %d 次请求 = %d %q", i, w.Code, w.Body)
+			}
+			continue
+		}
+		if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), `"code":"internal_error"`) {
+			t.Fatalf("第 %d 次请求 = %d %q，期望 Recovery 的 500", i, w.Code, w.Body)
+		}
+	}
+	if calls != 3 {
+		t.Fatalf("处理器执行了 %d 次，期望 3 次（panic 不缓存，正常响应缓存一次）", calls)
+	}
+}
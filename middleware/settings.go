@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"pic/i18n"
+	"pic/settings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 解析 multipart 时保存在内存中的最大字节数，超出部分落到临时文件
+const multipartMemory = 32 << 20
+
+// RegistrationOpen 系统设置关闭注册时拒绝注册请求
+func RegistrationOpen() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !settings.Get().RegistrationOpen {
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.Error(c, i18n.RegistrationClosed))
+			return
+		}
+		c.Next()
+	}
+}
+
+// PublicGalleryEnabled 系统设置关闭公开图库时拒绝访问
+func PublicGalleryEnabled() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !settings.Get().PublicGalleryEnabled {
+			c.AbortWithStatusJSON(http.StatusForbidden, i18n.Error(c, i18n.GalleryDisabled))
+			return
+		}
+		c.Next()
+	}
+}
+
+// UploadPolicy 按系统设置限制上传请求大小和文件类型。
+// 会提前解析 multipart 表单，后续处理器通过 c.FormFile / c.MultipartForm 读取解析结果。
+func UploadPolicy() gin.HandlerFunc {
+	return uploadPolicy(multipartMemory)
+}
+
+func uploadPolicy(maxMemory int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := settings.Get()
+		maxBytes := s.MaxUploadSizeMB << 20
+
+		if c.Request.ContentLength > maxBytes {
+			abortTooLarge(c, s.MaxUploadSizeMB)
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		if err := c.Request.ParseMultipartForm(maxMemory); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				abortTooLarge(c, s.MaxUploadSizeMB)
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, i18n.Error(c, i18n.InvalidUploadForm))
+			return
+		}
+		// net/http 只清理原始请求上的表单，而 c.Request 可能已被 RequestID 等中间件替换为
+		// WithContext 副本，临时文件需要在这里删除
+		form := c.Request.MultipartForm
+		defer form.RemoveAll()
+
+		for _, files := range form.File {
+			for _, fh := range files {
+				if !s.AllowsExtension(filepath.Ext(fh.Filename)) {
+					body := i18n.Error(c, i18n.UnsupportedFileType, fh.Filename)
+					body["allowed_types"] = s.AllowedFileTypes
+					c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, body)
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
+
+func abortTooLarge(c *gin.Context, limitMB int64) {
+	body := i18n.Error(c, i18n.FileTooLarge)
+	body["limit_mb"] = limitMB
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, body)
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newUploadRequest(t *testing.T, filename string, size int) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(bytes.Repeat([]byte("x"), size))
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// 超出内存上限的文件会落到临时文件，请求结束后必须删除，即使 c.Request 已被替换为副本
+func TestUploadPolicyRemovesTempFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		filename string
+		status   int
+	}{
+		{"accepted", "a.png", http.StatusOK},
+		{"rejected type", "a.exe", http.StatusUnsupportedMediaType},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// multipart 的临时文件建在 os.TempDir() 下
+			dir := t.TempDir()
+			t.Setenv("TMPDIR", dir)
+
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(c.Request.Context())
+			})
+			r.Use(uploadPolicy(1024))
+			r.POST("/upload", func(c *gin.Context) {
+				fh, err := c.FormFile("file")
+				if err != nil {
+					t.Errorf("处理器读取不到已解析的文件: %v", err)
+				}
+				f, err := fh.Open()
+				if err != nil {
+					t.Errorf("处理器打不开上传的文件: %v", err)
+					return
+				}
+				f.Close()
+				c.Status(http.StatusOK)
+			})
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, newUploadRequest(t, tc.filename, 8192))
+
+			if w.Code != tc.status {
+				t.Fatalf("状态码 = %d, 期望 %d: %s", w.Code, tc.status, w.Body)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Fatalf("临时文件没有被删除: %v", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"pic/config"
+	"pic/i18n"
+	"pic/settings"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceMode 系统设置开启维护模式时，除管理接口、公告（前端据此展示维护提示）和
+// 可选的公开图库浏览外，所有 API 返回 503。前端页面、健康检查和指标不受影响。
+func MaintenanceMode() gin.HandlerFunc {
+	return maintenanceMode(settings.Get)
+}
+
+func maintenanceMode(current func() settings.Settings) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s := current()
+		if !s.MaintenanceMode {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		read := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+		switch {
+		case !strings.HasPrefix(path, "/api/"),
+			strings.HasPrefix(path, "/api/admin/"),
+			read && strings.HasPrefix(path, "/api/announcements"),
+			read && strings.HasPrefix(path, "/api/docs"),
+			read && s.MaintenanceGalleryReadable && strings.HasPrefix(path, "/api/gallery/"):
+			c.Next()
+			return
+		}
+
+		body := i18n.Error(c, i18n.Maintenance)
+		// 管理员填写的提示不做翻译
+		if s.MaintenanceMessage != "" {
+			body["error"] = s.MaintenanceMessage
+		}
+		body["maintenance"] = true
+		c.Header("Retry-After", "300")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, body)
+	}
+}
+
+// ReadOnlyMode 只读模式（部署配置 read_only 或系统设置 read_only）下拒绝 API 写请求。
+// 登录和管理接口除外：用户仍可登录浏览，管理员可以关闭只读模式。
+func ReadOnlyMode() gin.HandlerFunc {
+	return readOnlyMode(func() bool { return config.Current().ReadOnly }, settings.Get)
+}
+
+func readOnlyMode(configReadOnly func() bool, current func() settings.Settings) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !configReadOnly() && !current().ReadOnly {
+			c.Next()
+			return
+		}
+
+		path := c.Request.URL.Path
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if !strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/api/admin/") || path == "/api/auth/login" {
+			c.Next()
+			return
+		}
+		body := i18n.Error(c, i18n.ReadOnly)
+		body["read_only"] = true
+		c.AbortWithStatusJSON(http.StatusForbidden, body)
+	}
+}
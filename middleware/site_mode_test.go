@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"pic/settings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func siteModeRouter(mw gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(mw)
+	r.NoRoute(func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return r
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		enabled         bool
+		galleryReadable bool
+		method, path    string
+		blocked         bool
+	}{
+		{"disabled", false, false, http.MethodPost, "/api/upload", false},
+		{"api blocked", true, false, http.MethodGet, "/api/images", true},
+		{"api write blocked", true, false, http.MethodPost, "/api/upload", true},
+		{"frontend", true, false, http.MethodGet, "/gallery/alice", false},
+		{"health", true, false, http.MethodGet, "/healthz", false},
+		{"admin read", true, false, http.MethodGet, "/api/admin/settings", false},
+		{"admin write", true, false, http.MethodPut, "/api/admin/settings", false},
+		{"announcements", true, false, http.MethodGet, "/api/announcements", false},
+		{"announcement dismiss", true, false, http.MethodPost, "/api/announcements/1/dismiss", true},
+		{"docs", true, false, http.MethodHead, "/api/docs/openapi.json", false},
+		{"gallery not readable", true, false, http.MethodGet, "/api/gallery/alice", true},
+		{"gallery readable", true, true, http.MethodGet, "/api/gallery/alice", false},
+		{"gallery write", true, true, http.MethodPost, "/api/gallery/alice", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := settings.Defaults()
+			s.MaintenanceMode, s.MaintenanceGalleryReadable = tc.enabled, tc.galleryReadable
+			r := siteModeRouter(maintenanceMode(func() settings.Settings { return s }))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			if blocked := w.Code == http.StatusServiceUnavailable; blocked != tc.blocked {
+				t.Fatalf("%s %s: 状态码 %d, 期望拦截 = %v", tc.method, tc.path, w.Code, tc.blocked)
+			}
+			if tc.blocked && w.Header().Get("Retry-After") != "300" {
+				t.Errorf("Retry-After = %q", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
+
+func TestMaintenanceModeMessage(t *testing.T) {
+	for _, tc := range []struct {
+		name, message, want string
+	}{
+		{"default", "", "The site is under maintenance, please try again later"},
+		// 管理员填写的提示原样返回，不做翻译
+		{"custom", "数据库迁移中，预计 10 分钟", "数据库迁移中，预计 10 分钟"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := settings.Defaults()
+			s.MaintenanceMode, s.MaintenanceMessage = true, tc.message
+			r := siteModeRouter(maintenanceMode(func() settings.Settings { return s }))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/images", nil)
+			req.Header.Set("Accept-Language", "en")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			var body struct {
+				Error       string `json:"error"`
+				Code        string `json:"code"`
+				Maintenance bool   `json:"maintenance"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != http.StatusServiceUnavailable || body.Error != tc.want || body.Code != "maintenance" || !body.Maintenance {
+				t.Fatalf("状态码 %d, 响应 %s", w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	for _, tc := range []struct {
+		name                     string
+		configFlag, settingsFlag bool
+		method, path             string
+		blocked                  bool
+	}{
+		{"disabled", false, false, http.MethodPost, "/api/upload", false},
+		{"config flag", true, false, http.MethodPost, "/api/upload", true},
+		{"settings flag", false, true, http.MethodPost, "/api/upload", true},
+		{"both flags", true, true, http.MethodDelete, "/api/images/1", true},
+		{"get", true, false, http.MethodGet, "/api/images", false},
+		{"head", true, false, http.MethodHead, "/api/images", false},
+		{"options", false, true, http.MethodOptions, "/api/upload", false},
+		{"put", false, true, http.MethodPut, "/api/config", true},
+		{"login", true, true, http.MethodPost, "/api/auth/login", false},
+		{"register", true, true, http.MethodPost, "/api/auth/register", true},
+		{"admin", true, true, http.MethodPut, "/api/admin/settings", false},
+		{"non api", true, true, http.MethodPost, "/webhook", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := settings.Defaults()
+			s.ReadOnly = tc.settingsFlag
+			r := siteModeRouter(readOnlyMode(func() bool { return tc.configFlag }, func() settings.Settings { return s }))
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			if blocked := w.Code == http.StatusForbidden; blocked != tc.blocked {
+				t.Fatalf("%s %s: 状态码 %d, 期望拦截 = %v", tc.method, tc.path, w.Code, tc.blocked)
+			}
+			if !tc.blocked {
+				return
+			}
+			var body struct {
+				Code     string `json:"code"`
+				ReadOnly bool   `json:"read_only"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "read_only" || !body.ReadOnly {
+				t.Fatalf("响应 %s (%v)", w.Body, err)
+			}
+		})
+	}
+}
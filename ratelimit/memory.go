@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full 桶不再被使用时重新装满的时间，之后删除与重新创建一个满桶等价
+	full time.Time
+}
+
+// MemoryLimiter 进程内令牌桶，只适用于单实例部署
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryLimiter 创建进程内限流器，并定期清理已重新装满的桶
+func NewMemoryLimiter(ctx context.Context) *MemoryLimiter {
+	l := &MemoryLimiter{buckets: make(map[string]*bucket)}
+	go l.janitor(ctx)
+	return l
+}
+
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) Result {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	var res Result
+	b.tokens, res = take(b.tokens, now.Sub(b.last), limit)
+	b.last, b.full = now, now.Add(res.ResetAfter)
+	return res
+}
+
+func (l *MemoryLimiter) Peek(_ context.Context, key string, limit Limit) Result {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		return peek(float64(limit.Burst), 0, limit)
+	}
+	return peek(b.tokens, now.Sub(b.last), limit)
+}
+
+// janitor 定期删除已经重新装满的桶。按桶各自的装满时间判断，
+// 100/d 这类慢速限额要等够 burst/rate 才会删除，不会因空闲较久而提前重置额度。
+func (l *MemoryLimiter) janitor(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.sweep(now)
+		}
+	}
+}
+
+func (l *MemoryLimiter) sweep(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for k, b := range l.buckets {
+		if now.After(b.full) {
+			delete(l.buckets, k)
+		}
+	}
+}
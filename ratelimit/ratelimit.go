@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"pic/i18n"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit 令牌桶参数：每秒补充 Rate 个令牌，桶容量为 Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled 为 false 时不做限制
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result 一次判定的结果
+type Result struct {
+	Allowed bool
+	// Remaining 本次之后桶内剩余的整数令牌数
+	Remaining int
+	// RetryAfter 被拒绝时距离下一个令牌可用的时间
+	RetryAfter time.Duration
+	// ResetAfter 桶重新装满所需的时间
+	ResetAfter time.Duration
+}
+
+// Limiter 令牌桶限流器
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) Result
+	// Peek 查询 key 当前的剩余令牌，不消耗令牌
+	Peek(ctx context.Context, key string, limit Limit) Result
+}
+
+// ParseLimit 解析 "N/单位" 形式的限额，单位为 s/m/h/d，桶容量等于 N。
+// 空字符串、"0" 或 "off" 表示不限制。
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" || s == "0" || s == "off" {
+		return Limit{}, nil
+	}
+	countStr, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, i18n.NewError(i18n.RateLimitBadFormat, s)
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count <= 0 {
+		return Limit{}, i18n.NewError(i18n.RateLimitBadCount, s)
+	}
+	var period time.Duration
+	switch unit {
+	case "s", "sec", "second":
+		period = time.Second
+	case "m", "min", "minute":
+		period = time.Minute
+	case "h", "hour":
+		period = time.Hour
+	case "d", "day":
+		period = 24 * time.Hour
+	default:
+		return Limit{}, i18n.NewError(i18n.RateLimitBadUnit, unit)
+	}
+	return Limit{Rate: float64(count) / period.Seconds(), Burst: count}, nil
+}
+
+// take 令牌桶核心计算，供各存储实现复用：返回新的令牌数与判定结果
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	tokens = math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+
+	var res Result
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	}
+	res.Remaining = int(tokens)
+	res.ResetAfter = time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second))
+	return tokens, res
+}
+
+// peek 只计算补充后的令牌数，不扣减；Allowed 表示下一次请求是否会被放行
+func peek(tokens float64, elapsed time.Duration, limit Limit) Result {
+	tokens = math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+
+	res := Result{Allowed: tokens >= 1, Remaining: int(tokens)}
+	if !res.Allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	}
+	res.ResetAfter = time.Duration((float64(limit.Burst) - tokens) / limit.Rate * float64(time.Second))
+	return res
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    Limit
+		wantErr bool
+	}{
+		{"", Limit{}, false},
+		{"off", Limit{}, false},
+		{"0", Limit{}, false},
+		{"10/s", Limit{Rate: 10, Burst: 10}, false},
+		{" 60/M ", Limit{Rate: 1, Burst: 60}, false},
+		{"3600/hour", Limit{Rate: 1, Burst: 3600}, false},
+		{"86400/d", Limit{Rate: 1, Burst: 86400}, false},
+		{"10", Limit{}, true},
+		{"-1/s", Limit{}, true},
+		{"x/s", Limit{}, true},
+		{"10/w", Limit{}, true},
+	} {
+		got, err := ParseLimit(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ParseLimit(%q) = %+v, %v，期望 %+v, 出错 %v", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+	limit := Limit{Rate: 1, Burst: 3}
+	for _, tc := range []struct {
+		name       string
+		tokens     float64
+		elapsed    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		resetAfter time.Duration
+	}{
+		{"full", 3, 0, true, 2, 0, time.Second},
+		{"last token", 1, 0, true, 0, 0, 3 * time.Second},
+		{"empty", 0, 0, false, 0, time.Second, 3 * time.Second},
+		{"half refilled", 0, 500 * time.Millisecond, false, 0, 500 * time.Millisecond, 2500 * time.Millisecond},
+		{"refill capped at burst", 0, time.Hour, true, 2, 0, time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, res := take(tc.tokens, tc.elapsed, limit)
+			want := Result{Allowed: tc.allowed, Remaining: tc.remaining, RetryAfter: tc.retryAfter, ResetAfter: tc.resetAfter}
+			if res != want {
+				t.Fatalf("take = %+v，期望 %+v", res, want)
+			}
+		})
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l := NewMemoryLimiter(ctx)
+	limit := Limit{Rate: 1, Burst: 2}
+
+	for i, want := range []bool{true, true, false} {
+		if res := l.Allow(ctx, "k", limit); res.Allowed != want {
+			t.Fatalf("第 %d 次 Allow = %+v，期望 Allowed=%v", i+1, res, want)
+		}
+	}
+	if res := l.Peek(ctx, "k", limit); res.Allowed || res.Remaining != 0 {
+		t.Fatalf("Peek = %+v", res)
+	}
+	if res := l.Peek(ctx, "other", limit); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("未使用的 key Peek = %+v", res)
+	}
+	// Peek 不消耗令牌
+	if res := l.Allow(ctx, "other", limit); !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("Peek 之后 Allow = %+v", res)
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l := NewMemoryLimiter(ctx)
+	// 每天 2 次：额度用完后空闲数小时不能被清理重置
+	limit := Limit{Rate: 2.0 / 86400, Burst: 2}
+	l.Allow(ctx, "k", limit)
+	l.Allow(ctx, "k", limit)
+
+	for _, tc := range []struct {
+		after time.Duration
+		kept  bool
+	}{
+		{time.Hour, true},
+		{12 * time.Hour, true},
+		{25 * time.Hour, false},
+	} {
+		l.sweep(time.Now().Add(tc.after))
+		l.mu.Lock()
+		_, kept := l.buckets["k"]
+		l.mu.Unlock()
+		if kept != tc.kept {
+			t.Fatalf("空闲 %v 后桶是否保留 = %v，期望 %v", tc.after, kept, tc.kept)
+		}
+		if kept {
+			if res := l.Allow(ctx, "k", limit); res.Allowed {
+				t.Fatalf("空闲 %v 后额度被重置: %+v", tc.after, res)
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript 在 Redis 中原子地完成令牌桶计算，时间使用 Redis 服务器时间，避免实例间时钟偏差。
+// 返回 {是否允许, 剩余令牌, 重试等待毫秒, 装满所需毫秒}
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + (math.max(0, now - ts) / 1000) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate * 1000)
+end
+local reset = math.ceil((burst - tokens) / rate * 1000)
+
+redis.call('HSET', KEYS[1], 'tokens', tokens, 'ts', now)
+redis.call('PEXPIRE', KEYS[1], reset + 1000)
+return {allowed, math.floor(tokens), retry, reset}
+`)
+
+// tokenBucketPeekScript 与 tokenBucketScript 相同的计算，但只读不扣减。
+// 返回 {下一次是否允许, 剩余令牌, 重试等待毫秒, 装满所需毫秒}
+var tokenBucketPeekScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + (math.max(0, now - ts) / 1000) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate * 1000)
+end
+return {allowed, math.floor(tokens), retry, math.ceil((burst - tokens) / rate * 1000)}
+`)
+
+// RedisLimiter 基于 Redis 的令牌桶，多实例共享计数
+type RedisLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisLimiter 创建 Redis 限流器
+func NewRedisLimiter(client *redis.Client) *RedisLimiter {
+	return &RedisLimiter{client: client}
+}
+
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) Result {
+	vals, err := tokenBucketScript.Run(ctx, l.client, []string{"pic:ratelimit:" + key}, limit.Rate, limit.Burst).Int64Slice()
+	if err != nil || len(vals) != 4 {
+		// Redis 故障时放行，限流不应成为单点故障
+		slog.Warn("Redis 限流失败，本次放行", "key", key, "error", err)
+		return Result{Allowed: true, Remaining: limit.Burst - 1}
+	}
+	return resultFromScript(vals)
+}
+
+func (l *RedisLimiter) Peek(ctx context.Context, key string, limit Limit) Result {
+	vals, err := tokenBucketPeekScript.Run(ctx, l.client, []string{"pic:ratelimit:" + key}, limit.Rate, limit.Burst).Int64Slice()
+	if err != nil || len(vals) != 4 {
+		slog.Warn("查询 Redis 限流状态失败", "key", key, "error", err)
+		return Result{Allowed: true, Remaining: limit.Burst}
+	}
+	return resultFromScript(vals)
+}
+
+func resultFromScript(vals []int64) Result {
+	return Result{
+		Allowed:    vals[0] == 1,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+		ResetAfter: time.Duration(vals[3]) * time.Millisecond,
+	}
+}
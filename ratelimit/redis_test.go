@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisLimiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	l := NewRedisLimiter(client)
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 2}
+
+	now := time.Unix(1700000000, 0)
+	mr.SetTime(now)
+
+	for _, tc := range []struct {
+		name      string
+		advance   time.Duration
+		peek      bool
+		allowed   bool
+		remaining int
+		retry     time.Duration
+		reset     time.Duration
+	}{
+		{"peek unused key", 0, true, true, 2, 0, 0},
+		{"first", 0, false, true, 1, 0, time.Second},
+		{"second", 0, false, true, 0, 0, 2 * time.Second},
+		{"empty", 0, false, false, 0, time.Second, 2 * time.Second},
+		{"peek empty", 0, true, false, 0, time.Second, 2 * time.Second},
+		{"half refilled", 500 * time.Millisecond, false, false, 0, 500 * time.Millisecond, 1500 * time.Millisecond},
+		{"one token refilled", 500 * time.Millisecond, false, true, 0, 0, 2 * time.Second},
+		{"refill capped at burst", time.Hour, true, true, 2, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.advance)
+			mr.SetTime(now)
+			var res Result
+			if tc.peek {
+				res = l.Peek(ctx, "k", limit)
+			} else {
+				res = l.Allow(ctx, "k", limit)
+			}
+			want := Result{Allowed: tc.allowed, Remaining: tc.remaining, RetryAfter: tc.retry, ResetAfter: tc.reset}
+			if res != want {
+				t.Fatalf("结果 %+v，期望 %+v", res, want)
+			}
+		})
+	}
+
+	// 过期时间覆盖装满所需时间，键过期时桶已满
+	if ttl := mr.TTL("pic:ratelimit:k"); ttl < 2*time.Second || ttl > 3*time.Second {
+		t.Fatalf("TTL = %v", ttl)
+	}
+}
+
+func TestRedisLimiterUnavailable(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	mr.Close()
+
+	// Redis 故障时放行
+	res := NewRedisLimiter(client).Allow(context.Background(), "k", Limit{Rate: 1, Burst: 5})
+	if !res.Allowed || res.Remaining != 4 {
+		t.Fatalf("Redis 不可用时 Allow = %+v", res)
+	}
+}
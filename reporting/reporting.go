@@ -0,0 +1,135 @@
+package reporting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// Event 一次需要上报的服务端错误
+type Event struct {
+	Time      time.Time         `json:"time"`
+	Message   string            `json:"message"`
+	Stack     string            `json:"stack,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Query     string            `json:"query,omitempty"`
+	ClientIP  string            `json:"client_ip"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// 上报时不携带的敏感请求头
+var sensitiveHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"X-Api-Key":     true,
+}
+
+var (
+	webhookURL    string
+	sentryEnabled bool
+	webhookClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// Init 设置 Webhook 地址和 Sentry DSN（可同时配置）。都为空时上报为空操作。
+func Init(sentryDSN, sentryEnvironment, webhook string) error {
+	webhookURL = webhook
+
+	if sentryDSN != "" {
+		err := sentry.Init(sentry.ClientOptions{
+			Dsn:              sentryDSN,
+			Environment:      sentryEnvironment,
+			AttachStacktrace: true,
+		})
+		if err != nil {
+			return fmt.Errorf("Sentry 初始化失败: %w", err)
+		}
+		sentryEnabled = true
+		slog.Info("🛰️ 已启用 Sentry 错误上报")
+	}
+	if webhookURL != "" {
+		slog.Info("🛰️ 已启用 Webhook 错误上报")
+	}
+	return nil
+}
+
+// NewEvent 根据请求构造事件，自动过滤敏感请求头
+func NewEvent(req *http.Request, message, stack, requestID, clientIP string) Event {
+	headers := make(map[string]string, len(req.Header))
+	for name, values := range req.Header {
+		if sensitiveHeaders[name] || len(values) == 0 {
+			continue
+		}
+		headers[name] = values[0]
+	}
+	return Event{
+		Time:      time.Now(),
+		Message:   message,
+		Stack:     stack,
+		RequestID: requestID,
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		Query:     req.URL.RawQuery,
+		ClientIP:  clientIP,
+		UserAgent: req.UserAgent(),
+		Headers:   headers,
+	}
+}
+
+// ReportPanic 上报 panic。需在 recover() 所在的 defer 中调用，Sentry 才能拿到 panic 时的调用栈。
+func ReportPanic(req *http.Request, recovered interface{}, event Event) {
+	if sentryEnabled {
+		hub := sentry.CurrentHub().Clone()
+		hub.Scope().SetRequest(req)
+		hub.Scope().SetTag("request_id", event.RequestID)
+		hub.RecoverWithContext(req.Context(), recovered)
+	}
+	sendWebhook(event)
+}
+
+// ReportError 上报非 panic 的错误（例如处理器记录到 c.Errors 的 5xx 错误）
+func ReportError(req *http.Request, err error, event Event) {
+	if sentryEnabled {
+		hub := sentry.CurrentHub().Clone()
+		hub.Scope().SetRequest(req)
+		hub.Scope().SetTag("request_id", event.RequestID)
+		hub.CaptureException(err)
+	}
+	sendWebhook(event)
+}
+
+// Flush 退出前等待 Sentry 发送完剩余事件
+func Flush(timeout time.Duration) {
+	if sentryEnabled {
+		sentry.Flush(timeout)
+	}
+}
+
+// sendWebhook 异步 POST JSON，失败只记日志，不影响请求
+func sendWebhook(event Event) {
+	if webhookURL == "" {
+		return
+	}
+	go func() {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		resp, err := webhookClient.Post(webhookURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			slog.Warn("错误上报 Webhook 发送失败", "error", err, "request_id", event.RequestID)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			slog.Warn("错误上报 Webhook 返回错误状态", "status", resp.StatusCode, "request_id", event.RequestID)
+		}
+	}()
+}
@@ -0,0 +1,231 @@
+package settings
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"pic/cluster"
+	"pic/i18n"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Settings 部署级别的系统设置，由管理员在运行时修改
+type Settings struct {
+	// RegistrationOpen 是否开放注册
+	RegistrationOpen bool `json:"registration_open"`
+	// AllowedFileTypes 允许上传的文件扩展名（小写，不含点）
+	AllowedFileTypes []string `json:"allowed_file_types"`
+	// MaxUploadSizeMB 单次上传请求的最大大小（MB）
+	MaxUploadSizeMB int64 `json:"max_upload_size_mb"`
+	// PublicGalleryEnabled 是否允许访问公开图库
+	PublicGalleryEnabled bool `json:"public_gallery_enabled"`
+	// ReadOnly 只读模式：拒绝上传、删除和配置修改，图库照常浏览（部署配置中的 read_only 同样生效）
+	ReadOnly bool `json:"read_only"`
+	// MaintenanceMode 维护模式：除管理接口和公告外的 API 返回 503，便于备份和迁移
+	MaintenanceMode bool `json:"maintenance_mode"`
+	// MaintenanceMessage 维护模式下返回给用户的说明，为空时使用默认文案
+	MaintenanceMessage string `json:"maintenance_message"`
+	// MaintenanceGalleryReadable 维护模式下是否仍允许浏览公开图库
+	MaintenanceGalleryReadable bool `json:"maintenance_gallery_readable"`
+}
+
+// Defaults 数据库中没有记录时使用的默认设置
+func Defaults() Settings {
+	return Settings{
+		RegistrationOpen:     true,
+		AllowedFileTypes:     []string{"jpg", "jpeg", "png", "gif", "webp", "svg", "bmp", "ico", "avif"},
+		MaxUploadSizeMB:      50,
+		PublicGalleryEnabled: true,
+
+		MaintenanceGalleryReadable: true,
+	}
+}
+
+// SystemSetting 设置表中的一行，Value 为 JSON 编码后的值
+type SystemSetting struct {
+	Key       string `gorm:"primaryKey;size:64"`
+	Value     string `gorm:"type:text"`
+	UpdatedAt time.Time
+}
+
+// refreshInterval 多实例部署时从数据库同步其它实例修改的间隔（广播丢失时的兜底）
+const refreshInterval = 30 * time.Second
+
+var (
+	db      *gorm.DB
+	current atomic.Pointer[Settings]
+	// updateMu 串行化 Patch，避免并发修改时按过期的当前值合并和比较差异
+	updateMu sync.Mutex
+)
+
+// Init 建表、加载设置并启动后台同步
+func Init(ctx context.Context, database *gorm.DB) error {
+	db = database
+	if err := db.AutoMigrate(&SystemSetting{}); err != nil {
+		return err
+	}
+	if err := Reload(); err != nil {
+		return err
+	}
+
+	// 其它实例修改设置后立即同步，定时同步作为兜底
+	cluster.Subscribe("settings", func(string) {
+		if err := Reload(); err != nil {
+			slog.Warn("同步系统设置失败", "error", err)
+		}
+	})
+
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := Reload(); err != nil {
+					slog.Warn("同步系统设置失败", "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Get 返回当前设置的副本（切片也会复制），调用方修改返回值不影响当前设置；Init 之前调用返回默认值
+func Get() Settings {
+	if s := current.Load(); s != nil {
+		return s.clone()
+	}
+	return Defaults()
+}
+
+func (s Settings) clone() Settings {
+	s.AllowedFileTypes = slices.Clone(s.AllowedFileTypes)
+	return s
+}
+
+// Reload 从数据库重新加载设置，未保存的项使用默认值
+func Reload() error {
+	var rows []SystemSetting
+	if err := db.Find(&rows).Error; err != nil {
+		return err
+	}
+
+	// 先编码默认值再逐项覆盖，这样新增的设置项自动取默认值
+	merged := map[string]json.RawMessage{}
+	defaults, _ := json.Marshal(Defaults())
+	_ = json.Unmarshal(defaults, &merged)
+	for _, row := range rows {
+		merged[row.Key] = json.RawMessage(row.Value)
+	}
+
+	raw, _ := json.Marshal(merged)
+	s := Defaults()
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return err
+	}
+	current.Store(&s)
+	return nil
+}
+
+// Normalized 返回规范化后的副本：文件类型转为小写并去掉前导的点
+func (s Settings) Normalized() Settings {
+	s = s.clone()
+	for i, ext := range s.AllowedFileTypes {
+		s.AllowedFileTypes[i] = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(ext)), ".")
+	}
+	return s
+}
+
+// Update 规范化、校验并保存设置，只写入与当前值不同的项。校验失败时当前设置保持不变。
+func Update(s Settings) error {
+	return Patch(func(cur *Settings) error {
+		*cur = s
+		return nil
+	})
+}
+
+// Patch 在持有更新锁时从数据库取得最新设置交给 fn 修改，再规范化、校验并保存与之不同的项。
+// fn 或校验返回错误时不做任何修改。并发的部分修改（包括其它实例的修改）不会互相覆盖。
+func Patch(fn func(*Settings) error) error {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+
+	// 本实例缓存的设置可能落后于其它实例的修改，以数据库为准
+	if err := Reload(); err != nil {
+		return err
+	}
+	old := Get()
+	s := old.clone()
+	if err := fn(&s); err != nil {
+		return err
+	}
+	s = s.Normalized()
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	var newFields, oldFields map[string]json.RawMessage
+	newRaw, _ := json.Marshal(s)
+	oldRaw, _ := json.Marshal(old)
+	_ = json.Unmarshal(newRaw, &newFields)
+	_ = json.Unmarshal(oldRaw, &oldFields)
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for key, value := range newFields {
+			if string(oldFields[key]) == string(value) {
+				continue
+			}
+			row := SystemSetting{Key: key, Value: string(value), UpdatedAt: time.Now()}
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := Reload(); err != nil {
+		return err
+	}
+	if err := cluster.Publish(context.Background(), "settings", ""); err != nil {
+		slog.Warn("通知其它实例同步系统设置失败", "error", err)
+	}
+	return nil
+}
+
+// Validate 检查设置是否合法
+func (s Settings) Validate() error {
+	if s.MaxUploadSizeMB <= 0 {
+		return i18n.NewError(i18n.SettingsBadUploadSize)
+	}
+	if len(s.AllowedFileTypes) == 0 {
+		return i18n.NewError(i18n.SettingsNoFileTypes)
+	}
+	for _, ext := range s.AllowedFileTypes {
+		if ext == "" || strings.ContainsAny(ext, "./\\ ") {
+			return i18n.NewError(i18n.SettingsBadFileType, ext)
+		}
+	}
+	return nil
+}
+
+// AllowsExtension 判断扩展名（可带点，大小写不敏感）是否允许上传
+func (s Settings) AllowsExtension(ext string) bool {
+	ext = strings.TrimPrefix(strings.ToLower(ext), ".")
+	for _, allowed := range s.AllowedFileTypes {
+		if allowed == ext {
+			return true
+		}
+	}
+	return false
+}
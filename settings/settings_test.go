@@ -0,0 +1,36 @@
+package settings
+
+import "testing"
+
+func TestGetReturnsCopy(t *testing.T) {
+	s := Defaults()
+	current.Store(&s)
+	t.Cleanup(func() { current.Store(nil) })
+
+	got := Get()
+	got.AllowedFileTypes[0] = "changed"
+	if Get().AllowedFileTypes[0] == "changed" {
+		t.Fatal("修改 Get 的返回值影响了当前设置")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(*Settings)
+		ok     bool
+	}{
+		{"defaults", func(*Settings) {}, true},
+		{"zero upload size", func(s *Settings) { s.MaxUploadSizeMB = 0 }, false},
+		{"no file types", func(s *Settings) { s.AllowedFileTypes = nil }, false},
+		{"dotted file type", func(s *Settings) { s.AllowedFileTypes = []string{"a.b"} }, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Defaults()
+			tc.modify(&s)
+			if err := s.Validate(); (err == nil) != tc.ok {
+				t.Fatalf("Validate() = %v, ok = %v", err, tc.ok)
+			}
+		})
+	}
+}
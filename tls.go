@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"pic/config"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// setupTLS 按配置为 srv 启用 HTTPS，返回需要一并启动的 HTTP 跳转服务器（未配置时为 nil）
+func setupTLS(srv *http.Server, cfg config.TLSConfig) *http.Server {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	var redirect http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirectToHTTPS(w, r, srv.Addr)
+	})
+
+	if len(cfg.AutocertDomains) > 0 {
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		srv.TLSConfig = m.TLSConfig()
+		// HTTP 端口同时处理 ACME http-01 验证
+		redirect = m.HTTPHandler(redirect)
+		slog.Info("🔒 已启用自动证书", "domains", cfg.AutocertDomains)
+	}
+
+	if cfg.RedirectHTTP == "" {
+		return nil
+	}
+	return &http.Server{
+		Addr:              cfg.RedirectHTTP,
+		Handler:           redirect,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// redirectToHTTPS 将请求永久跳转到同一主机的 HTTPS 地址
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request, tlsAddr string) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if _, port, err := net.SplitHostPort(tlsAddr); err == nil && port != "443" {
+		host = net.JoinHostPort(host, port)
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"gorm.io/gorm"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
+)
+
+// ServiceName 上报到链路追踪后端的默认服务名，可用 OTEL_SERVICE_NAME 覆盖
+const ServiceName = "pic"
+
+// Enabled 是否配置了 OTLP 导出地址
+func Enabled() bool {
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Init 初始化 OTLP/HTTP 导出器，并为 GORM 和默认 HTTP 客户端加上追踪。
+// 未配置 OTEL_EXPORTER_OTLP_ENDPOINT 时不做任何事。导出器的其余参数（headers、
+// 采样率等）沿用 OpenTelemetry 标准环境变量。返回的函数用于退出前刷新剩余 span。
+func Init(ctx context.Context, db *gorm.DB) (func(context.Context) error, error) {
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// OTEL_SERVICE_NAME 等环境变量优先
+	if envRes, err := resource.New(ctx, resource.WithFromEnv()); err == nil {
+		if merged, err := resource.Merge(res, envRes); err == nil {
+			res = merged
+		}
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	if db != nil {
+		if err := db.Use(gormtracing.NewPlugin()); err != nil {
+			slog.Warn("⚠️ GORM 追踪插件注册失败", "error", err)
+		}
+	}
+
+	// 其它走默认 HTTP 客户端的外部请求，GitHub 请求使用单独的 Transport（见 Transport）
+	http.DefaultTransport = otelhttp.NewTransport(http.DefaultTransport)
+
+	slog.Info("🔭 OpenTelemetry 链路追踪已启用")
+	return provider.Shutdown, nil
+}
+
+// Transport 为 rt 加上链路追踪，未启用追踪时 span 为空操作
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(rt)
+}